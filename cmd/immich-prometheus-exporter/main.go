@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
+	"maps"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,9 +14,14 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/victorarias/immich-prometheus-exporter/internal/collector"
+	"github.com/victorarias/immich-prometheus-exporter/internal/config"
 	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+	"github.com/victorarias/immich-prometheus-exporter/internal/state"
 )
 
+// How often API key files are checked for rotated credentials
+const keyFileCheckInterval = 30 * time.Second
+
 // Build info set by ldflags
 var (
 	version = "dev"
@@ -23,22 +30,30 @@ var (
 )
 
 func main() {
-	immichURL := os.Getenv("IMMICH_URL")
-	apiKey := os.Getenv("IMMICH_API_KEY")
-	listenAddr := os.Getenv("LISTEN_ADDRESS")
-
-	if immichURL == "" || apiKey == "" {
-		log.Fatal("IMMICH_URL and IMMICH_API_KEY environment variables are required")
+	configFile := flag.String("config.file", "", "Path to YAML configuration file")
+	userLabel := flag.String("user-label", "", "How users are identified in per-user series: name, id, both or hashed (default from config, else name)")
+	collectorFlags := collector.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
 	}
 
-	if listenAddr == "" {
-		listenAddr = ":8080"
+	if *userLabel != "" {
+		cfg.Labels.User = *userLabel
+	}
+	userLabelMode, err := collector.ParseUserLabelMode(cfg.Labels.User)
+	if err != nil {
+		log.Fatalf("Invalid --user-label: %v", err)
 	}
 
-	log.Printf("Immich Prometheus Exporter %s (commit: %s, built: %s)", version, commit, date)
+	// Command line flags take precedence over the config file
+	enabledCollectors := make(map[string]bool)
+	maps.Copy(enabledCollectors, cfg.Collectors)
+	maps.Copy(enabledCollectors, collectorFlags())
 
-	client := immich.NewClient(immichURL, apiKey)
-	coll := collector.New(client)
+	log.Printf("Immich Prometheus Exporter %s (commit: %s, built: %s)", version, commit, date)
 
 	// Register build info metric
 	buildInfo := prometheus.NewGaugeVec(
@@ -52,21 +67,120 @@ func main() {
 	)
 	buildInfo.WithLabelValues(version, commit, date).Set(1)
 	prometheus.MustRegister(buildInfo)
-	prometheus.MustRegister(coll)
+
+	store := state.Memory()
+	if cfg.State.File != "" {
+		store, err = state.Open(cfg.State.File)
+		if err != nil {
+			log.Fatalf("Error loading state: %v", err)
+		}
+	}
+
+	constLabels := prometheus.Labels(cfg.Labels.Constant)
+	var keyFiles []*immich.KeyFile
+	var pollers []*collector.Poller
+
+	// newCollector builds the collector for one Immich instance, refreshed in
+	// the background when polling is enabled
+	newCollector := func(client *immich.Client, machineLearningURL string, store *state.Store) scrapeCollector {
+		collectors := maps.Clone(enabledCollectors)
+		// Configuring a machine learning URL enables its probe unless the
+		// collector was disabled explicitly
+		if _, ok := collectors["machine_learning"]; !ok && machineLearningURL != "" {
+			collectors["machine_learning"] = true
+		}
+		coll := collector.New(client,
+			collector.WithCollectors(collectors),
+			collector.WithUserLabel(userLabelMode),
+			collector.WithAlbumSeriesLimit(cfg.Albums.MaxSeries),
+			collector.WithSharedLinkExpiryWindow(cfg.SharedLinks.ExpiryWindow),
+			collector.WithMachineLearningURL(machineLearningURL),
+			collector.WithInventorySeriesLimit(cfg.Inventory.MaxSeries),
+			collector.WithInventoryRescanInterval(cfg.Inventory.FullRescanInterval),
+			collector.WithStateStore(store),
+			collector.WithQueueStallWindow(cfg.Jobs.StallWindow, cfg.Jobs.StallWindows),
+			collector.WithForecastWindow(cfg.Forecast.Window, cfg.Forecast.SampleInterval),
+		)
+		if cfg.Polling.Interval <= 0 {
+			return coll
+		}
+		poller := collector.NewPoller(coll, cfg.Polling.Interval, cfg.Polling.MaxStaleness)
+		pollers = append(pollers, poller)
+		return poller
+	}
 
 	mux := http.NewServeMux()
-	mux.Handle("/metrics", promhttp.Handler())
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		if err := client.Ping(); err != nil {
-			w.WriteHeader(http.StatusServiceUnavailable)
-			w.Write([]byte("unhealthy: " + err.Error()))
-			return
+
+	if cfg.Immich.URL != "" {
+		client := immich.NewClient(cfg.Immich.URL, cfg.Immich.APIKey, immich.WithTimeout(cfg.Immich.Timeout))
+		if cfg.Immich.APIKeyFile != "" {
+			keyFile, err := loadKeyFile(cfg.Immich.APIKeyFile, client)
+			if err != nil {
+				log.Fatalf("Error loading API key: %v", err)
+			}
+			keyFiles = append(keyFiles, keyFile)
+		}
+		mux.Handle("/metrics", scrapeHandler(prometheus.DefaultGatherer, newCollector(client, cfg.Immich.MachineLearningURL, store.Scope("immich")), constLabels, cfg.Web.ScrapeTimeoutOffset))
+
+		mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+			if err := client.Ping(r.Context()); err != nil {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte("unhealthy: " + err.Error()))
+				return
+			}
+			w.Write([]byte("ok"))
+		})
+	} else {
+		mux.Handle("/metrics", promhttp.Handler())
+		mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		})
+	}
+
+	// Collectors for multi-target probing are built once so each target
+	// keeps a single client across requests
+	probeCollectors := make(map[string]scrapeCollector, len(cfg.Targets))
+	for _, name := range cfg.TargetNames() {
+		target := cfg.Targets[name]
+		client := immich.NewClient(target.URL, target.APIKey, immich.WithTimeout(cfg.Immich.Timeout))
+		if target.APIKeyFile != "" {
+			keyFile, err := loadKeyFile(target.APIKeyFile, client)
+			if err != nil {
+				log.Fatalf("Error loading API key for target %s: %v", name, err)
+			}
+			keyFiles = append(keyFiles, keyFile)
 		}
-		w.Write([]byte("ok"))
-	})
+		probeCollectors[name] = newCollector(client, target.MachineLearningURL, store.Scope("targets").Scope(name))
+	}
+	if len(probeCollectors) > 0 {
+		log.Printf("Configured %d probe targets", len(probeCollectors))
+		mux.Handle("/probe", probeHandler(probeCollectors, constLabels, cfg.Web.ScrapeTimeoutOffset))
+	}
+
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	if len(pollers) > 0 {
+		log.Printf("Polling Immich every %s", cfg.Polling.Interval)
+	}
+	for _, poller := range pollers {
+		go poller.Run(backgroundCtx)
+	}
+
+	// Rotated API keys are picked up periodically or immediately on SIGHUP
+	for _, keyFile := range keyFiles {
+		go keyFile.Watch(backgroundCtx, keyFileCheckInterval)
+	}
+	go func() {
+		hupChan := make(chan os.Signal, 1)
+		signal.Notify(hupChan, syscall.SIGHUP)
+		for range hupChan {
+			immich.ReloadAll(keyFiles)
+		}
+	}()
 
 	server := &http.Server{
-		Addr:    listenAddr,
+		Addr:    cfg.Web.ListenAddress,
 		Handler: mux,
 	}
 
@@ -78,6 +192,7 @@ func main() {
 		<-sigChan
 
 		log.Println("Shutting down...")
+		stopBackground()
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
@@ -87,7 +202,7 @@ func main() {
 		done <- true
 	}()
 
-	log.Printf("Listening on %s", listenAddr)
+	log.Printf("Listening on %s", cfg.Web.ListenAddress)
 	if err := server.ListenAndServe(); err != http.ErrServerClosed {
 		log.Fatalf("HTTP server error: %v", err)
 	}
@@ -95,3 +210,12 @@ func main() {
 	<-done
 	log.Println("Stopped")
 }
+
+// loadKeyFile reads the initial API key for client from path
+func loadKeyFile(path string, client *immich.Client) (*immich.KeyFile, error) {
+	keyFile := immich.NewKeyFile(path, client)
+	if _, err := keyFile.Reload(); err != nil {
+		return nil, err
+	}
+	return keyFile, nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// probeHandler serves /probe?target=<name>, scraping a single configured
+// Immich instance into a fresh registry per request
+func probeHandler(collectors map[string]scrapeCollector, constLabels prometheus.Labels, timeoutOffset time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("target")
+		if name == "" {
+			http.Error(w, "target parameter is missing", http.StatusBadRequest)
+			return
+		}
+
+		coll, ok := collectors[name]
+		if !ok {
+			http.Error(w, "unknown target: "+name, http.StatusNotFound)
+			return
+		}
+
+		scrapeHandler(nil, coll, constLabels, timeoutOffset).ServeHTTP(w, r)
+	}
+}
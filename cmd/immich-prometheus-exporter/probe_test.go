@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// staticCollector is a scrapeCollector exporting a single fixed gauge
+type staticCollector struct {
+	desc *prometheus.Desc
+}
+
+func newStaticCollector() *staticCollector {
+	return &staticCollector{desc: prometheus.NewDesc("immich_test_up", "Test gauge", nil, nil)}
+}
+
+func (c *staticCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *staticCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, 1)
+}
+
+func (c *staticCollector) WithContext(ctx context.Context) prometheus.Collector {
+	return c
+}
+
+func TestProbeHandler(t *testing.T) {
+	handler := probeHandler(map[string]scrapeCollector{"prod": newStaticCollector()}, prometheus.Labels{"cluster": "home"}, 0)
+
+	tests := []struct {
+		name       string
+		url        string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "missing target", url: "/probe", wantStatus: http.StatusBadRequest, wantBody: "target parameter is missing"},
+		{name: "unknown target", url: "/probe?target=staging", wantStatus: http.StatusNotFound, wantBody: "unknown target: staging"},
+		{name: "known target", url: "/probe?target=prod", wantStatus: http.StatusOK, wantBody: `immich_test_up{cluster="home"} 1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("expected body to contain %q, got %q", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// scrapeCollector is a collector whose Immich requests can be bound to the
+// context of a scrape
+type scrapeCollector interface {
+	prometheus.Collector
+	WithContext(ctx context.Context) prometheus.Collector
+}
+
+// scrapeHandler gathers coll into a fresh registry per request, bound to the
+// scrape deadline, together with the metrics from base (if any)
+func scrapeHandler(base prometheus.Gatherer, coll scrapeCollector, constLabels prometheus.Labels, timeoutOffset time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := scrapeContext(r, timeoutOffset)
+		defer cancel()
+
+		registry := prometheus.NewRegistry()
+		prometheus.WrapRegistererWith(constLabels, registry).MustRegister(coll.WithContext(ctx))
+
+		gatherers := prometheus.Gatherers{registry}
+		if base != nil {
+			gatherers = append(gatherers, base)
+		}
+		promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	})
+}
+
+// scrapeContext derives a deadline from the scrape timeout Prometheus sends,
+// minus offset so partial metrics are still returned in time
+func scrapeContext(r *http.Request, offset time.Duration) (context.Context, context.CancelFunc) {
+	seconds, err := strconv.ParseFloat(r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"), 64)
+	if err != nil || seconds <= 0 {
+		return context.WithCancel(r.Context())
+	}
+
+	timeout := time.Duration(seconds * float64(time.Second))
+	if timeout > offset {
+		timeout -= offset
+	}
+	return context.WithTimeout(r.Context(), timeout)
+}
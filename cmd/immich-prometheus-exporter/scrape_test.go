@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScrapeContext(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       string
+		offset       time.Duration
+		wantDeadline bool
+		wantTimeout  time.Duration
+	}{
+		{name: "missing header", offset: 500 * time.Millisecond},
+		{name: "garbage header", header: "soon", offset: 500 * time.Millisecond},
+		{name: "non-positive timeout", header: "0", offset: 500 * time.Millisecond},
+		{name: "offset subtracted", header: "10", offset: 500 * time.Millisecond, wantDeadline: true, wantTimeout: 9500 * time.Millisecond},
+		{name: "fractional seconds", header: "2.5", offset: 500 * time.Millisecond, wantDeadline: true, wantTimeout: 2 * time.Second},
+		{name: "timeout equal to offset", header: "0.5", offset: 500 * time.Millisecond, wantDeadline: true, wantTimeout: 500 * time.Millisecond},
+		{name: "timeout below offset", header: "0.2", offset: 500 * time.Millisecond, wantDeadline: true, wantTimeout: 200 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/metrics", nil)
+			if tt.header != "" {
+				r.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", tt.header)
+			}
+
+			start := time.Now()
+			ctx, cancel := scrapeContext(r, tt.offset)
+			defer cancel()
+
+			deadline, ok := ctx.Deadline()
+			if ok != tt.wantDeadline {
+				t.Fatalf("expected deadline %v, got %v", tt.wantDeadline, ok)
+			}
+			if !ok {
+				return
+			}
+			// Allow for the time taken between start and scrapeContext
+			if got := deadline.Sub(start); got < tt.wantTimeout || got > tt.wantTimeout+50*time.Millisecond {
+				t.Errorf("expected timeout of %s, got %s", tt.wantTimeout, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"context"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+// Default number of albums exported with their own asset count series
+const defaultAlbumSeriesLimit = 50
+
+func init() {
+	registerCollector("albums", false, newAlbumsCollector)
+}
+
+// albumsCollector exports album counts by sharing and owner, and asset counts
+// for the largest albums
+type albumsCollector struct {
+	userLabel   UserLabelMode
+	seriesLimit int
+
+	albums        *prometheus.Desc
+	albumsByOwner *prometheus.Desc
+	albumAssets   *prometheus.Desc
+	albumsDropped *prometheus.Desc
+}
+
+func newAlbumsCollector(opts options) Collector {
+	return &albumsCollector{
+		userLabel:   opts.userLabel,
+		seriesLimit: opts.albumSeriesLimit,
+
+		albums: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "albums"),
+			"Number of albums by sharing (shared or private)",
+			[]string{"sharing"}, nil,
+		),
+		albumsByOwner: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "albums_by_owner"),
+			"Number of albums per owner",
+			opts.userLabel.labelNames(), nil,
+		),
+		albumAssets: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "album", "assets"),
+			"Number of assets per album, for the largest albums only",
+			[]string{"album_id", "album"}, nil,
+		),
+		albumsDropped: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "album", "series_dropped"),
+			"Number of albums left out of immich_album_assets by the series limit",
+			nil, nil,
+		),
+	}
+}
+
+func (c *albumsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.albums
+	ch <- c.albumsByOwner
+	ch <- c.albumAssets
+	ch <- c.albumsDropped
+}
+
+func (c *albumsCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	albums, err := client.GetAlbums(ctx)
+	if err != nil {
+		return err
+	}
+
+	var shared, private float64
+	owners := make(map[string]immich.User)
+	byOwner := make(map[string]float64)
+	for _, album := range albums {
+		if album.Shared {
+			shared++
+		} else {
+			private++
+		}
+		owners[album.OwnerID] = album.Owner
+		byOwner[album.OwnerID]++
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.albums, prometheus.GaugeValue, shared, "shared")
+	ch <- prometheus.MustNewConstMetric(c.albums, prometheus.GaugeValue, private, "private")
+	for ownerID, count := range byOwner {
+		labels := c.userLabel.labelValues(ownerID, owners[ownerID].Name)
+		ch <- prometheus.MustNewConstMetric(c.albumsByOwner, prometheus.GaugeValue, count, labels...)
+	}
+
+	// Keep cardinality bounded by only exporting the largest albums
+	sort.Slice(albums, func(i, j int) bool {
+		if albums[i].AssetCount != albums[j].AssetCount {
+			return albums[i].AssetCount > albums[j].AssetCount
+		}
+		return albums[i].ID < albums[j].ID
+	})
+	limit := min(c.seriesLimit, len(albums))
+	for _, album := range albums[:limit] {
+		ch <- prometheus.MustNewConstMetric(c.albumAssets, prometheus.GaugeValue, float64(album.AssetCount), album.ID, album.AlbumName)
+	}
+	ch <- prometheus.MustNewConstMetric(c.albumsDropped, prometheus.GaugeValue, float64(len(albums)-limit))
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func TestAlbumsCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"id":"a1","albumName":"Holidays","ownerId":"u1","owner":{"id":"u1","name":"alice"},"assetCount":42,"shared":true},
+			{"id":"a2","albumName":"Pets","ownerId":"u1","owner":{"id":"u1","name":"alice"},"assetCount":7,"shared":false},
+			{"id":"a3","albumName":"Garden","ownerId":"u2","owner":{"id":"u2","name":"bob"},"assetCount":100,"shared":false}
+		]`))
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("albums"), WithAlbumSeriesLimit(2))
+
+	expected := `
+		# HELP immich_albums Number of albums by sharing (shared or private)
+		# TYPE immich_albums gauge
+		immich_albums{sharing="private"} 2
+		immich_albums{sharing="shared"} 1
+		# HELP immich_albums_by_owner Number of albums per owner
+		# TYPE immich_albums_by_owner gauge
+		immich_albums_by_owner{user="alice"} 2
+		immich_albums_by_owner{user="bob"} 1
+		# HELP immich_album_assets Number of assets per album, for the largest albums only
+		# TYPE immich_album_assets gauge
+		immich_album_assets{album="Garden",album_id="a3"} 100
+		immich_album_assets{album="Holidays",album_id="a1"} 42
+		# HELP immich_album_series_dropped Number of albums left out of immich_album_assets by the series limit
+		# TYPE immich_album_series_dropped gauge
+		immich_album_series_dropped 1
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"immich_albums", "immich_albums_by_owner", "immich_album_assets", "immich_album_series_dropped"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
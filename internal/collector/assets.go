@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func init() {
+	registerCollector("assets", false, newAssetsCollector)
+}
+
+// assetsCollector exports how many assets still lack derived artifacts,
+// giving the real backlog behind the job queue depths. It pages through all
+// assets of the API key's user, so prefer running it with background polling.
+//
+// Smart search embeddings and OCR results are not exposed per asset by the
+// Immich API, so they are not covered.
+type assetsCollector struct {
+	assetsInspected *prometheus.Desc
+	assetsMissing   *prometheus.Desc
+}
+
+func newAssetsCollector(opts options) Collector {
+	return &assetsCollector{
+		assetsInspected: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "assets", "inspected"),
+			"Number of assets inspected for missing artifacts",
+			nil, nil,
+		),
+		assetsMissing: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "assets", "missing"),
+			"Number of assets lacking a derived artifact",
+			[]string{"artifact"}, nil,
+		),
+	}
+}
+
+func (c *assetsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.assetsInspected
+	ch <- c.assetsMissing
+}
+
+func (c *assetsCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	var inspected, missingThumbnail, missingMetadata float64
+	err := client.ForEachAsset(ctx, immich.MetadataSearchRequest{WithExif: true}, func(asset immich.Asset) {
+		inspected++
+		if asset.Thumbhash == nil {
+			missingThumbnail++
+		}
+		if asset.ExifInfo == nil {
+			missingMetadata++
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	encoded := false
+	missingEncodedVideo, err := client.CountAssets(ctx, immich.StatisticsSearchRequest{Type: "VIDEO", IsEncoded: &encoded})
+	if err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.assetsInspected, prometheus.GaugeValue, inspected)
+	ch <- prometheus.MustNewConstMetric(c.assetsMissing, prometheus.GaugeValue, missingThumbnail, "thumbnail")
+	ch <- prometheus.MustNewConstMetric(c.assetsMissing, prometheus.GaugeValue, missingMetadata, "metadata")
+	ch <- prometheus.MustNewConstMetric(c.assetsMissing, prometheus.GaugeValue, float64(missingEncodedVideo), "encoded_video")
+	return nil
+}
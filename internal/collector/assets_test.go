@@ -0,0 +1,43 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func TestAssetsCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/search/metadata":
+			w.Write([]byte(`{"assets":{"items":[
+				{"id":"a1","thumbhash":"abc","exifInfo":{"make":"Canon"}},
+				{"id":"a2","thumbhash":null,"exifInfo":{}},
+				{"id":"a3","thumbhash":null,"exifInfo":null}
+			],"nextPage":null}}`))
+		case "/api/search/statistics":
+			w.Write([]byte(`{"total":4}`))
+		}
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("assets"))
+
+	expected := `
+		# HELP immich_assets_inspected Number of assets inspected for missing artifacts
+		# TYPE immich_assets_inspected gauge
+		immich_assets_inspected 3
+		# HELP immich_assets_missing Number of assets lacking a derived artifact
+		# TYPE immich_assets_missing gauge
+		immich_assets_missing{artifact="encoded_video"} 4
+		immich_assets_missing{artifact="metadata"} 1
+		immich_assets_missing{artifact="thumbnail"} 2
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "immich_assets_inspected", "immich_assets_missing"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
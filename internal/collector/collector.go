@@ -1,149 +1,212 @@
 package collector
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+	"github.com/victorarias/immich-prometheus-exporter/internal/state"
 )
 
 const namespace = "immich"
 
-type ImmichCollector struct {
-	client *immich.Client
-
-	// Job metrics (per-queue)
-	jobActive    *prometheus.Desc
-	jobWaiting   *prometheus.Desc
-	jobFailed    *prometheus.Desc
-	jobDelayed   *prometheus.Desc
-	jobPaused    *prometheus.Desc
-	jobCompleted *prometheus.Desc
-	queueActive  *prometheus.Desc
-	queuePaused  *prometheus.Desc
-
-	// Library metrics
-	libraryPhotos *prometheus.Desc
-	libraryVideos *prometheus.Desc
-	libraryBytes  *prometheus.Desc
-	userPhotos    *prometheus.Desc
-	userVideos    *prometheus.Desc
-	userBytes     *prometheus.Desc
-
-	// Storage metrics
-	storageTotal        *prometheus.Desc
-	storageUsed         *prometheus.Desc
-	storageAvailable    *prometheus.Desc
-	storageUsagePercent *prometheus.Desc
+// Collector is a sub-collector exporting one area of the Immich API.
+// Sub-collectors register themselves in init via registerCollector.
+type Collector interface {
+	Describe(ch chan<- *prometheus.Desc)
+	// Update fetches from Immich and sends the resulting metrics to ch.
+	// It must give up when ctx is done.
+	Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error
+}
 
-	// Exporter metrics
-	scrapeDuration *prometheus.Desc
-	scrapeSuccess  *prometheus.Desc
+// options are the settings shared with sub-collectors when they are created
+type options struct {
+	userLabel              UserLabelMode
+	albumSeriesLimit       int
+	sharedLinkExpiryWindow time.Duration
+	machineLearningURL     string
+	// Inventory collector cardinality cap and full scan interval
+	inventorySeriesLimit    int
+	inventoryRescanInterval time.Duration
+	// Where collectors keep what they derive across updates
+	state *state.Store
+	// Time without progress before a busy queue counts as stalled, with
+	// per-queue overrides
+	queueStallWindow  time.Duration
+	queueStallWindows map[string]time.Duration
+	// Span of the forecast sample window and minimum time between samples
+	forecastWindow         time.Duration
+	forecastSampleInterval time.Duration
 }
 
-func New(client *immich.Client) *ImmichCollector {
-	return &ImmichCollector{
-		client: client,
+type factory struct {
+	defaultEnabled bool
+	new            func(opts options) Collector
+}
 
-		// Job metrics
-		jobActive: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "job", "active"),
-			"Number of active jobs",
-			[]string{"queue"}, nil,
-		),
-		jobWaiting: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "job", "waiting"),
-			"Number of waiting jobs",
-			[]string{"queue"}, nil,
-		),
-		jobFailed: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "job", "failed"),
-			"Number of failed jobs",
-			[]string{"queue"}, nil,
-		),
-		jobDelayed: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "job", "delayed"),
-			"Number of delayed jobs",
-			[]string{"queue"}, nil,
-		),
-		jobPaused: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "job", "paused"),
-			"Number of paused jobs",
-			[]string{"queue"}, nil,
-		),
-		jobCompleted: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "job", "completed"),
-			"Number of completed jobs",
-			[]string{"queue"}, nil,
-		),
-		queueActive: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "queue", "active"),
-			"Whether queue is active (1=yes, 0=no)",
-			[]string{"queue"}, nil,
-		),
-		queuePaused: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "queue", "paused"),
-			"Whether queue is paused (1=yes, 0=no)",
-			[]string{"queue"}, nil,
-		),
+var factories = make(map[string]factory)
 
-		// Library metrics
-		libraryPhotos: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "library", "photos"),
-			"Total photos",
-			nil, nil,
-		),
-		libraryVideos: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "library", "videos"),
-			"Total videos",
-			nil, nil,
-		),
-		libraryBytes: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "library", "bytes"),
-			"Total storage usage in bytes",
-			nil, nil,
-		),
-		userPhotos: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "user", "photos"),
-			"Photos per user",
-			[]string{"user"}, nil,
-		),
-		userVideos: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "user", "videos"),
-			"Videos per user",
-			[]string{"user"}, nil,
-		),
-		userBytes: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "user", "bytes"),
-			"Storage per user in bytes",
-			[]string{"user"}, nil,
-		),
+func registerCollector(name string, defaultEnabled bool, newCollector func(opts options) Collector) {
+	factories[name] = factory{defaultEnabled: defaultEnabled, new: newCollector}
+}
 
-		// Storage metrics
-		storageTotal: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "storage", "total_bytes"),
-			"Total disk size",
-			nil, nil,
-		),
-		storageUsed: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "storage", "used_bytes"),
-			"Disk used",
-			nil, nil,
-		),
-		storageAvailable: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "storage", "available_bytes"),
-			"Disk available",
-			nil, nil,
-		),
-		storageUsagePercent: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "storage", "usage_percent"),
-			"Disk usage percentage",
-			nil, nil,
-		),
+// Names returns the registered collectors in sorted order
+func Names() []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegisterFlags adds --collector.<name> and --no-collector.<name> flags for
+// every registered collector. After parsing, the returned function reports
+// the collectors explicitly enabled or disabled on the command line.
+func RegisterFlags(fs *flag.FlagSet) func() map[string]bool {
+	for _, name := range Names() {
+		fs.Bool("collector."+name, factories[name].defaultEnabled, fmt.Sprintf("Enable the %s collector", name))
+		fs.Bool("no-collector."+name, false, fmt.Sprintf("Disable the %s collector", name))
+	}
+
+	return func() map[string]bool {
+		overrides := make(map[string]bool)
+		// Other flags share the set, so values are only read from ours
+		fs.Visit(func(f *flag.Flag) {
+			if name, ok := strings.CutPrefix(f.Name, "no-collector."); ok {
+				overrides[name] = !f.Value.(flag.Getter).Get().(bool)
+			} else if name, ok := strings.CutPrefix(f.Name, "collector."); ok {
+				overrides[name] = f.Value.(flag.Getter).Get().(bool)
+			}
+		})
+		return overrides
+	}
+}
+
+// Option configures an ImmichCollector
+type Option func(*ImmichCollector)
+
+// WithCollectors enables or disables collectors by name. Collectors not
+// present in the map keep their default.
+func WithCollectors(enabled map[string]bool) Option {
+	return func(c *ImmichCollector) {
+		for name, on := range enabled {
+			c.enabled[name] = on
+		}
+	}
+}
+
+// WithUserLabel sets how users are identified in per-user series
+func WithUserLabel(mode UserLabelMode) Option {
+	return func(c *ImmichCollector) {
+		c.opts.userLabel = mode
+	}
+}
+
+// WithAlbumSeriesLimit caps how many albums get their own asset count series
+func WithAlbumSeriesLimit(limit int) Option {
+	return func(c *ImmichCollector) {
+		c.opts.albumSeriesLimit = limit
+	}
+}
+
+// WithSharedLinkExpiryWindow sets how soon a shared link must expire to be
+// counted as expiring
+func WithSharedLinkExpiryWindow(window time.Duration) Option {
+	return func(c *ImmichCollector) {
+		c.opts.sharedLinkExpiryWindow = window
+	}
+}
+
+// WithMachineLearningURL sets the machine learning service probed by the
+// machine_learning collector
+func WithMachineLearningURL(url string) Option {
+	return func(c *ImmichCollector) {
+		c.opts.machineLearningURL = url
+	}
+}
+
+// WithInventorySeriesLimit caps how many MIME types, file extensions and camera makes
+// get their own inventory series
+func WithInventorySeriesLimit(limit int) Option {
+	return func(c *ImmichCollector) {
+		c.opts.inventorySeriesLimit = limit
+	}
+}
+
+// WithInventoryRescanInterval sets how often the inventory collector scans
+// all assets instead of only the changed ones
+func WithInventoryRescanInterval(interval time.Duration) Option {
+	return func(c *ImmichCollector) {
+		c.opts.inventoryRescanInterval = interval
+	}
+}
+
+// WithStateStore sets where collectors persist derived state such as
+// counters. By default state is kept in memory only.
+func WithStateStore(store *state.Store) Option {
+	return func(c *ImmichCollector) {
+		c.opts.state = store
+	}
+}
+
+// WithQueueStallWindow sets how long a queue with active jobs may go without
+// progress before it counts as stalled. Queues in perQueue use their own
+// window.
+func WithQueueStallWindow(window time.Duration, perQueue map[string]time.Duration) Option {
+	return func(c *ImmichCollector) {
+		c.opts.queueStallWindow = window
+		c.opts.queueStallWindows = perQueue
+	}
+}
+
+// WithForecastWindow sets the span of samples storage forecasts are fitted
+// on, and the minimum time between samples
+func WithForecastWindow(window, sampleInterval time.Duration) Option {
+	return func(c *ImmichCollector) {
+		c.opts.forecastWindow = window
+		c.opts.forecastSampleInterval = sampleInterval
+	}
+}
+
+// ImmichCollector runs the enabled sub-collectors in parallel on every scrape
+type ImmichCollector struct {
+	client     *immich.Client
+	opts       options
+	enabled    map[string]bool
+	collectors map[string]Collector
+
+	// Exporter metrics
+	scrapeDuration          *prometheus.Desc
+	scrapeSuccess           *prometheus.Desc
+	scrapeCollectorDuration *prometheus.Desc
+	scrapeCollectorSuccess  *prometheus.Desc
+}
+
+func New(client *immich.Client, opts ...Option) *ImmichCollector {
+	c := &ImmichCollector{
+		client: client,
+		opts: options{
+			userLabel:               UserLabelName,
+			albumSeriesLimit:        defaultAlbumSeriesLimit,
+			sharedLinkExpiryWindow:  defaultSharedLinkExpiryWindow,
+			inventorySeriesLimit:    defaultInventorySeriesLimit,
+			inventoryRescanInterval: defaultInventoryRescanInterval,
+			state:                   state.Memory(),
+			queueStallWindow:        defaultQueueStallWindow,
+			forecastWindow:          defaultForecastWindow,
+			forecastSampleInterval:  defaultForecastSampleInterval,
+		},
+		enabled:    make(map[string]bool),
+		collectors: make(map[string]Collector),
 
-		// Exporter metrics
 		scrapeDuration: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "scrape", "duration_seconds"),
 			"Time taken to scrape",
@@ -154,110 +217,119 @@ func New(client *immich.Client) *ImmichCollector {
 			"Whether scrape succeeded (1=yes, 0=no)",
 			nil, nil,
 		),
+		scrapeCollectorDuration: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "scrape", "collector_duration_seconds"),
+			"Time taken by each collector to fetch from Immich",
+			[]string{"collector"}, nil,
+		),
+		scrapeCollectorSuccess: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "scrape", "collector_success"),
+			"Whether each collector succeeded (1=yes, 0=no)",
+			[]string{"collector"}, nil,
+		),
 	}
+
+	for name, f := range factories {
+		c.enabled[name] = f.defaultEnabled
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	for name, f := range factories {
+		if c.enabled[name] {
+			c.collectors[name] = f.new(c.opts)
+		}
+	}
+	return c
 }
 
 func (c *ImmichCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- c.jobActive
-	ch <- c.jobWaiting
-	ch <- c.jobFailed
-	ch <- c.jobDelayed
-	ch <- c.jobPaused
-	ch <- c.jobCompleted
-	ch <- c.queueActive
-	ch <- c.queuePaused
-	ch <- c.libraryPhotos
-	ch <- c.libraryVideos
-	ch <- c.libraryBytes
-	ch <- c.userPhotos
-	ch <- c.userVideos
-	ch <- c.userBytes
-	ch <- c.storageTotal
-	ch <- c.storageUsed
-	ch <- c.storageAvailable
-	ch <- c.storageUsagePercent
+	for _, coll := range c.collectors {
+		coll.Describe(ch)
+	}
 	ch <- c.scrapeDuration
 	ch <- c.scrapeSuccess
+	ch <- c.scrapeCollectorDuration
+	ch <- c.scrapeCollectorSuccess
 }
 
 func (c *ImmichCollector) Collect(ch chan<- prometheus.Metric) {
-	start := time.Now()
-	success := 1.0
+	c.collect(context.Background(), ch)
+}
 
-	var wg sync.WaitGroup
-	var jobsResp immich.JobsResponse
-	var statsResp *immich.StatisticsResponse
-	var storageResp *immich.StorageResponse
-	var jobsErr, statsErr, storageErr error
+// WithContext returns a view of the collector whose Immich requests are
+// bound to ctx, typically derived from the scrape request
+func (c *ImmichCollector) WithContext(ctx context.Context) prometheus.Collector {
+	return &contextCollector{collector: c, ctx: ctx}
+}
 
-	// Fetch all APIs in parallel
-	wg.Add(3)
+type contextCollector struct {
+	collector *ImmichCollector
+	ctx       context.Context
+}
 
-	go func() {
-		defer wg.Done()
-		jobsResp, jobsErr = c.client.GetJobs()
-	}()
+func (c *contextCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.collector.Describe(ch)
+}
 
-	go func() {
-		defer wg.Done()
-		statsResp, statsErr = c.client.GetStatistics()
-	}()
+func (c *contextCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collector.collect(c.ctx, ch)
+}
 
-	go func() {
-		defer wg.Done()
-		storageResp, storageErr = c.client.GetStorage()
-	}()
+// collect runs all enabled collectors in parallel and reports whether all of
+// them succeeded. Collectors still running when ctx is done fail, and the
+// metrics of the others are kept.
+func (c *ImmichCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) bool {
+	start := time.Now()
+	ctx = withScrapeCache(ctx)
 
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	success := true
+	for name, coll := range c.collectors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.update(ctx, name, coll, ch); err != nil {
+				mu.Lock()
+				success = false
+				mu.Unlock()
+			}
+		}()
+	}
 	wg.Wait()
 
-	// Process job metrics
-	if jobsErr != nil {
-		log.Printf("Error fetching jobs: %v", jobsErr)
-		success = 0
-	} else {
-		for queueName, queue := range jobsResp {
-			ch <- prometheus.MustNewConstMetric(c.jobActive, prometheus.GaugeValue, float64(queue.JobCounts.Active), queueName)
-			ch <- prometheus.MustNewConstMetric(c.jobWaiting, prometheus.GaugeValue, float64(queue.JobCounts.Waiting), queueName)
-			ch <- prometheus.MustNewConstMetric(c.jobFailed, prometheus.GaugeValue, float64(queue.JobCounts.Failed), queueName)
-			ch <- prometheus.MustNewConstMetric(c.jobDelayed, prometheus.GaugeValue, float64(queue.JobCounts.Delayed), queueName)
-			ch <- prometheus.MustNewConstMetric(c.jobPaused, prometheus.GaugeValue, float64(queue.JobCounts.Paused), queueName)
-			ch <- prometheus.MustNewConstMetric(c.jobCompleted, prometheus.GaugeValue, float64(queue.JobCounts.Completed), queueName)
-			ch <- prometheus.MustNewConstMetric(c.queueActive, prometheus.GaugeValue, boolToFloat(queue.QueueStatus.IsActive), queueName)
-			ch <- prometheus.MustNewConstMetric(c.queuePaused, prometheus.GaugeValue, boolToFloat(queue.QueueStatus.IsPaused), queueName)
-		}
-	}
+	// Exporter metrics
+	duration := time.Since(start).Seconds()
+	ch <- prometheus.MustNewConstMetric(c.scrapeDuration, prometheus.GaugeValue, duration)
+	ch <- prometheus.MustNewConstMetric(c.scrapeSuccess, prometheus.GaugeValue, boolToFloat(success))
+	return success
+}
 
-	// Process statistics metrics
-	if statsErr != nil {
-		log.Printf("Error fetching statistics: %v", statsErr)
-		success = 0
-	} else {
-		ch <- prometheus.MustNewConstMetric(c.libraryPhotos, prometheus.GaugeValue, float64(statsResp.Photos))
-		ch <- prometheus.MustNewConstMetric(c.libraryVideos, prometheus.GaugeValue, float64(statsResp.Videos))
-		ch <- prometheus.MustNewConstMetric(c.libraryBytes, prometheus.GaugeValue, float64(statsResp.Usage))
-
-		for _, user := range statsResp.UsageByUser {
-			ch <- prometheus.MustNewConstMetric(c.userPhotos, prometheus.GaugeValue, float64(user.Photos), user.UserName)
-			ch <- prometheus.MustNewConstMetric(c.userVideos, prometheus.GaugeValue, float64(user.Videos), user.UserName)
-			ch <- prometheus.MustNewConstMetric(c.userBytes, prometheus.GaugeValue, float64(user.Usage), user.UserName)
-		}
-	}
+// update runs a single collector and reports how it went, so failures of one
+// endpoint can be told apart from Immich being down
+func (c *ImmichCollector) update(ctx context.Context, name string, coll Collector, ch chan<- prometheus.Metric) error {
+	start := time.Now()
+	err := coll.Update(ctx, c.client, ch)
+	duration := time.Since(start).Seconds()
 
-	// Process storage metrics
-	if storageErr != nil {
-		log.Printf("Error fetching storage: %v", storageErr)
+	success := 1.0
+	if err != nil {
+		log.Printf("Error collecting %s: %v", name, err)
 		success = 0
-	} else {
-		ch <- prometheus.MustNewConstMetric(c.storageTotal, prometheus.GaugeValue, float64(storageResp.DiskSize))
-		ch <- prometheus.MustNewConstMetric(c.storageUsed, prometheus.GaugeValue, float64(storageResp.DiskUse))
-		ch <- prometheus.MustNewConstMetric(c.storageAvailable, prometheus.GaugeValue, float64(storageResp.DiskAvailable))
-		ch <- prometheus.MustNewConstMetric(c.storageUsagePercent, prometheus.GaugeValue, storageResp.DiskUsagePercentage)
 	}
+	ch <- prometheus.MustNewConstMetric(c.scrapeCollectorDuration, prometheus.GaugeValue, duration, name)
+	ch <- prometheus.MustNewConstMetric(c.scrapeCollectorSuccess, prometheus.GaugeValue, success, name)
+	return err
+}
 
-	// Exporter metrics
-	duration := time.Since(start).Seconds()
-	ch <- prometheus.MustNewConstMetric(c.scrapeDuration, prometheus.GaugeValue, duration)
-	ch <- prometheus.MustNewConstMetric(c.scrapeSuccess, prometheus.GaugeValue, success)
+// parseTimestamp converts an Immich ISO 8601 timestamp to Unix seconds
+func parseTimestamp(s string) (float64, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, err
+	}
+	return float64(t.UnixNano()) / 1e9, nil
 }
 
 func boolToFloat(b bool) float64 {
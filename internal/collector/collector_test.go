@@ -1,17 +1,30 @@
 package collector
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
 )
 
+// onlyCollectors enables the named collectors and disables all others
+func onlyCollectors(names ...string) Option {
+	enabled := make(map[string]bool)
+	for _, name := range Names() {
+		enabled[name] = slices.Contains(names, name)
+	}
+	return WithCollectors(enabled)
+}
+
 func TestCollector_Collect(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
@@ -98,6 +111,10 @@ func TestCollector_ScrapeSuccess(t *testing.T) {
 			json.NewEncoder(w).Encode(immich.StatisticsResponse{})
 		case "/api/server/storage":
 			json.NewEncoder(w).Encode(immich.StorageResponse{})
+		case "/api/server/about":
+			json.NewEncoder(w).Encode(immich.ServerAboutResponse{Version: "v1.135.3"})
+		case "/api/server/version-check":
+			json.NewEncoder(w).Encode(immich.VersionCheckResponse{})
 		}
 	}))
 	defer server.Close()
@@ -134,3 +151,123 @@ func TestCollector_ScrapeFailure(t *testing.T) {
 		t.Errorf("unexpected metric value: %v", err)
 	}
 }
+
+func TestCollector_DisabledCollectors(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		json.NewEncoder(w).Encode(immich.JobsResponse{})
+	}))
+	defer server.Close()
+
+	client := immich.NewClient(server.URL, "test-key")
+	collector := New(client, WithCollectors(map[string]bool{"statistics": false, "storage": false, "server": false}))
+
+	expected := `
+		# HELP immich_scrape_success Whether scrape succeeded (1=yes, 0=no)
+		# TYPE immich_scrape_success gauge
+		immich_scrape_success 1
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "immich_scrape_success"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+
+	if len(requested) != 1 || requested[0] != "/api/jobs" {
+		t.Errorf("expected only /api/jobs to be requested, got %v", requested)
+	}
+}
+
+func TestCollector_CollectorSuccess(t *testing.T) {
+	// Statistics require admin rights and fail, the other endpoints work
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/jobs":
+			json.NewEncoder(w).Encode(immich.JobsResponse{})
+		case "/api/server/statistics":
+			w.WriteHeader(http.StatusForbidden)
+		case "/api/server/storage":
+			json.NewEncoder(w).Encode(immich.StorageResponse{})
+		}
+	}))
+	defer server.Close()
+
+	client := immich.NewClient(server.URL, "test-key")
+	collector := New(client, WithCollectors(map[string]bool{"server": false}))
+
+	expected := `
+		# HELP immich_scrape_collector_success Whether each collector succeeded (1=yes, 0=no)
+		# TYPE immich_scrape_collector_success gauge
+		immich_scrape_collector_success{collector="jobs"} 1
+		immich_scrape_collector_success{collector="statistics"} 0
+		immich_scrape_collector_success{collector="storage"} 1
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "immich_scrape_collector_success"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+
+	if count := testutil.CollectAndCount(collector, "immich_scrape_collector_duration_seconds"); count != 3 {
+		t.Errorf("expected 3 collector duration series, got %d", count)
+	}
+}
+
+func TestRegisterFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("config.file", "", "Path to YAML configuration file")
+	overrides := RegisterFlags(fs)
+
+	if err := fs.Parse([]string{"--config.file=c.yaml", "--no-collector.statistics", "--collector.storage=false"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := overrides()
+	if len(got) != 2 || got["statistics"] || got["storage"] {
+		t.Errorf("expected statistics and storage disabled, got %v", got)
+	}
+	if _, ok := got["jobs"]; ok {
+		t.Error("expected jobs to keep its default")
+	}
+}
+
+func TestNames(t *testing.T) {
+	names := Names()
+	for _, want := range []string{"jobs", "statistics", "storage"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("expected %s collector to be registered, got %v", want, names)
+		}
+	}
+}
+
+func TestCollector_WithContextPartialMetrics(t *testing.T) {
+	// Statistics hang until the scrape deadline, the other endpoints answer
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/jobs":
+			json.NewEncoder(w).Encode(immich.JobsResponse{})
+		case "/api/server/statistics":
+			<-r.Context().Done()
+		case "/api/server/storage":
+			json.NewEncoder(w).Encode(immich.StorageResponse{DiskSize: 1000})
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	client := immich.NewClient(server.URL, "test-key")
+	collector := New(client, WithCollectors(map[string]bool{"server": false})).WithContext(ctx)
+
+	expected := `
+		# HELP immich_scrape_collector_success Whether each collector succeeded (1=yes, 0=no)
+		# TYPE immich_scrape_collector_success gauge
+		immich_scrape_collector_success{collector="jobs"} 1
+		immich_scrape_collector_success{collector="statistics"} 0
+		immich_scrape_collector_success{collector="storage"} 1
+		# HELP immich_storage_total_bytes Total disk size
+		# TYPE immich_storage_total_bytes gauge
+		immich_storage_total_bytes 1000
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "immich_scrape_collector_success", "immich_storage_total_bytes"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func init() {
+	registerCollector("credentials", false, newCredentialsCollector)
+}
+
+// credentialsCollector exports an inventory of sessions and API keys to spot
+// stale or excessive credentials. Sessions are listed for every user, which
+// requires an admin API key. Immich only lists the API keys of the key's own
+// user, so API key series cover that user only.
+type credentialsCollector struct {
+	now       func() time.Time
+	userLabel UserLabelMode
+
+	sessions         *prometheus.Desc
+	oldestSessionAge *prometheus.Desc
+	apiKeys          *prometheus.Desc
+	oldestAPIKeyAge  *prometheus.Desc
+}
+
+func newCredentialsCollector(opts options) Collector {
+	return &credentialsCollector{
+		now:       time.Now,
+		userLabel: opts.userLabel,
+
+		sessions: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "sessions"),
+			"Number of active sessions per user and device type",
+			append(opts.userLabel.labelNames(), "device_type"), nil,
+		),
+		oldestSessionAge: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "session", "oldest_age_seconds"),
+			"Age of the oldest active session per user",
+			opts.userLabel.labelNames(), nil,
+		),
+		apiKeys: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "api_keys"),
+			"Number of API keys per user",
+			opts.userLabel.labelNames(), nil,
+		),
+		oldestAPIKeyAge: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "api_key", "oldest_age_seconds"),
+			"Age of the oldest API key per user",
+			opts.userLabel.labelNames(), nil,
+		),
+	}
+}
+
+func (c *credentialsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sessions
+	ch <- c.oldestSessionAge
+	ch <- c.apiKeys
+	ch <- c.oldestAPIKeyAge
+}
+
+func (c *credentialsCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	now := c.now()
+
+	users, err := client.GetAdminUsers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.DeletedAt != "" {
+			continue
+		}
+		sessions, err := client.GetUserSessions(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		c.collectSessions(ch, now, user.ID, user.Name, sessions)
+	}
+
+	me, err := client.GetMyUser(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := client.GetAPIKeys(ctx)
+	if err != nil {
+		return err
+	}
+	labels := c.userLabel.labelValues(me.ID, me.Name)
+	ch <- prometheus.MustNewConstMetric(c.apiKeys, prometheus.GaugeValue, float64(len(keys)), labels...)
+	var oldest time.Time
+	for _, key := range keys {
+		oldest = older(oldest, key.CreatedAt)
+	}
+	if !oldest.IsZero() {
+		ch <- prometheus.MustNewConstMetric(c.oldestAPIKeyAge, prometheus.GaugeValue, now.Sub(oldest).Seconds(), labels...)
+	}
+	return nil
+}
+
+func (c *credentialsCollector) collectSessions(ch chan<- prometheus.Metric, now time.Time, userID, userName string, sessions []immich.Session) {
+	byDevice := make(map[string]float64)
+	var oldest time.Time
+	for _, session := range sessions {
+		if session.ExpiresAt != nil {
+			if expiresAt, err := time.Parse(time.RFC3339, *session.ExpiresAt); err == nil && expiresAt.Before(now) {
+				continue
+			}
+		}
+		deviceType := session.DeviceType
+		if deviceType == "" {
+			deviceType = "unknown"
+		}
+		byDevice[deviceType]++
+		oldest = older(oldest, session.CreatedAt)
+	}
+
+	labels := c.userLabel.labelValues(userID, userName)
+	for deviceType, count := range byDevice {
+		ch <- prometheus.MustNewConstMetric(c.sessions, prometheus.GaugeValue, count, append(labels, deviceType)...)
+	}
+	if !oldest.IsZero() {
+		ch <- prometheus.MustNewConstMetric(c.oldestSessionAge, prometheus.GaugeValue, now.Sub(oldest).Seconds(), labels...)
+	}
+}
+
+// older returns the earlier of t and the timestamp s, ignoring s when it
+// cannot be parsed. A zero t counts as unset.
+func older(t time.Time, s string) time.Time {
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return t
+	}
+	if t.IsZero() || parsed.Before(t) {
+		return parsed
+	}
+	return t
+}
@@ -0,0 +1,72 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func TestCredentialsCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/admin/users":
+			w.Write([]byte(`[
+				{"id":"u1","name":"alice"},
+				{"id":"u2","name":"bob"},
+				{"id":"u3","name":"carol","deletedAt":"2025-06-01T00:00:00.000Z"}
+			]`))
+		case "/api/admin/users/u1/sessions":
+			w.Write([]byte(`[
+				{"id":"s1","createdAt":"2025-06-01T12:00:00.000Z","deviceType":"iOS","expiresAt":null},
+				{"id":"s2","createdAt":"2025-06-30T12:00:00.000Z","deviceType":"iOS","expiresAt":null},
+				{"id":"s3","createdAt":"2025-06-20T12:00:00.000Z","deviceType":"Chrome","expiresAt":"2025-08-01T00:00:00.000Z"},
+				{"id":"s4","createdAt":"2025-01-01T12:00:00.000Z","deviceType":"Firefox","expiresAt":"2025-02-01T00:00:00.000Z"}
+			]`))
+		case "/api/admin/users/u2/sessions":
+			w.Write([]byte(`[{"id":"s5","createdAt":"2025-06-30T12:00:00.000Z","deviceType":"","expiresAt":null}]`))
+		case "/api/users/me":
+			w.Write([]byte(`{"id":"u1","name":"alice"}`))
+		case "/api/api-keys":
+			w.Write([]byte(`[
+				{"id":"k1","name":"exporter","createdAt":"2025-05-31T12:00:00.000Z"},
+				{"id":"k2","name":"backup","createdAt":"2025-06-30T12:00:00.000Z"}
+			]`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("credentials"))
+	collector.collectors["credentials"].(*credentialsCollector).now = func() time.Time {
+		return time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	}
+
+	expected := `
+		# HELP immich_api_key_oldest_age_seconds Age of the oldest API key per user
+		# TYPE immich_api_key_oldest_age_seconds gauge
+		immich_api_key_oldest_age_seconds{user="alice"} 2.6784e+06
+		# HELP immich_api_keys Number of API keys per user
+		# TYPE immich_api_keys gauge
+		immich_api_keys{user="alice"} 2
+		# HELP immich_session_oldest_age_seconds Age of the oldest active session per user
+		# TYPE immich_session_oldest_age_seconds gauge
+		immich_session_oldest_age_seconds{user="alice"} 2.592e+06
+		immich_session_oldest_age_seconds{user="bob"} 86400
+		# HELP immich_sessions Number of active sessions per user and device type
+		# TYPE immich_sessions gauge
+		immich_sessions{device_type="Chrome",user="alice"} 1
+		immich_sessions{device_type="iOS",user="alice"} 2
+		immich_sessions{device_type="unknown",user="bob"} 1
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"immich_api_key_oldest_age_seconds", "immich_api_keys", "immich_session_oldest_age_seconds", "immich_sessions"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
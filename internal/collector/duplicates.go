@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func init() {
+	registerCollector("duplicates", false, newDuplicatesCollector)
+}
+
+// duplicatesCollector exports what duplicate detection found, to quantify how
+// much storage a cleanup would free
+type duplicatesCollector struct {
+	groups           *prometheus.Desc
+	assets           *prometheus.Desc
+	reclaimableBytes *prometheus.Desc
+}
+
+func newDuplicatesCollector(opts options) Collector {
+	return &duplicatesCollector{
+		groups: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "duplicates", "groups"),
+			"Number of groups of duplicate assets",
+			nil, nil,
+		),
+		assets: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "duplicates", "assets"),
+			"Number of assets in duplicate groups",
+			nil, nil,
+		),
+		reclaimableBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "duplicates", "reclaimable_bytes"),
+			"Bytes freed by keeping only the largest asset of each duplicate group",
+			nil, nil,
+		),
+	}
+}
+
+func (c *duplicatesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.groups
+	ch <- c.assets
+	ch <- c.reclaimableBytes
+}
+
+func (c *duplicatesCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	groups, err := client.GetDuplicates(ctx)
+	if err != nil {
+		return err
+	}
+
+	var assets, reclaimable float64
+	for _, group := range groups {
+		var total, largest int64
+		for _, asset := range group.Assets {
+			if asset.ExifInfo == nil {
+				continue
+			}
+			size := asset.ExifInfo.FileSizeInByte
+			total += size
+			largest = max(largest, size)
+		}
+		assets += float64(len(group.Assets))
+		reclaimable += float64(total - largest)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.groups, prometheus.GaugeValue, float64(len(groups)))
+	ch <- prometheus.MustNewConstMetric(c.assets, prometheus.GaugeValue, assets)
+	ch <- prometheus.MustNewConstMetric(c.reclaimableBytes, prometheus.GaugeValue, reclaimable)
+	return nil
+}
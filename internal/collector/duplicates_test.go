@@ -0,0 +1,49 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func TestDuplicatesCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/duplicates" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`[
+			{"duplicateId":"d1","assets":[
+				{"id":"a1","exifInfo":{"fileSizeInByte":300}},
+				{"id":"a2","exifInfo":{"fileSizeInByte":100}},
+				{"id":"a3","exifInfo":{"fileSizeInByte":200}}
+			]},
+			{"duplicateId":"d2","assets":[
+				{"id":"a4","exifInfo":{"fileSizeInByte":50}},
+				{"id":"a5","exifInfo":null}
+			]}
+		]`))
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("duplicates"))
+
+	expected := `
+		# HELP immich_duplicates_assets Number of assets in duplicate groups
+		# TYPE immich_duplicates_assets gauge
+		immich_duplicates_assets 5
+		# HELP immich_duplicates_groups Number of groups of duplicate assets
+		# TYPE immich_duplicates_groups gauge
+		immich_duplicates_groups 2
+		# HELP immich_duplicates_reclaimable_bytes Bytes freed by keeping only the largest asset of each duplicate group
+		# TYPE immich_duplicates_reclaimable_bytes gauge
+		immich_duplicates_reclaimable_bytes 300
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"immich_duplicates_assets", "immich_duplicates_groups", "immich_duplicates_reclaimable_bytes"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
@@ -0,0 +1,277 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+	"github.com/victorarias/immich-prometheus-exporter/internal/state"
+)
+
+const (
+	// Default span of samples the forecast is fitted on
+	defaultForecastWindow = 7 * 24 * time.Hour
+	// Default minimum time between kept samples, bounding the window size
+	defaultForecastSampleInterval = time.Hour
+	// Samples needed before forecasting
+	minForecastSamples = 3
+)
+
+// MaxForecastSamples bounds the samples in the forecast window, since the fit
+// compares every pair of samples
+const MaxForecastSamples = 1000
+
+func init() {
+	registerCollector("forecast", false, newForecastCollector)
+}
+
+// forecastSample is a value at a Unix time in seconds
+type forecastSample struct {
+	T int64   `json:"t"`
+	V float64 `json:"v"`
+}
+
+// forecastState is the sample window, kept in the state store across restarts
+type forecastState struct {
+	// Usage at which the disk is full: used plus available space, which is
+	// less than the disk size on filesystems reserving blocks for root
+	DiskCapacity int64                       `json:"disk_capacity"`
+	DiskUse      []forecastSample            `json:"disk_use"`
+	Users        map[string][]forecastSample `json:"users"`
+}
+
+// forecastCollector forecasts storage growth from a rolling window of
+// samples. It uses the Theil–Sen estimator, the median slope between all
+// pairs of samples, which unlike least squares is not thrown off by a few
+// outliers such as a large import followed by a cleanup.
+type forecastCollector struct {
+	now            func() time.Time
+	userLabel      UserLabelMode
+	store          *state.Store
+	window         time.Duration
+	sampleInterval time.Duration
+
+	// Updates are serialized since they share the window
+	mu        sync.Mutex
+	state     *forecastState
+	loaded    bool
+	fetchedAt time.Time
+	// Fits are only recomputed when a sample is added
+	fitted   bool
+	diskFit  forecastFit
+	userFits map[string]forecastFit
+
+	predictedFull *prometheus.Desc
+	storageGrowth *prometheus.Desc
+	userGrowth    *prometheus.Desc
+	samples       *prometheus.Desc
+}
+
+// forecastFit is a line fitted through a sample window
+type forecastFit struct {
+	slope, intercept float64
+	ok               bool
+}
+
+func newForecastCollector(opts options) Collector {
+	// Sample less often rather than exceed the sample limit
+	sampleInterval := max(opts.forecastSampleInterval, opts.forecastWindow/MaxForecastSamples)
+	return &forecastCollector{
+		now:            time.Now,
+		userLabel:      opts.userLabel,
+		store:          opts.state,
+		window:         opts.forecastWindow,
+		sampleInterval: sampleInterval,
+
+		predictedFull: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "storage", "predicted_full_timestamp_seconds"),
+			"Unix time the disk is predicted to be full at the current growth rate (+Inf when not growing)",
+			nil, nil,
+		),
+		storageGrowth: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "storage", "growth_bytes_per_second"),
+			"Growth rate of disk usage over the forecast window",
+			nil, nil,
+		),
+		userGrowth: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "user", "growth_bytes_per_second"),
+			"Growth rate of storage used per user over the forecast window",
+			opts.userLabel.labelNames(), nil,
+		),
+		samples: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "storage", "forecast_samples"),
+			"Number of disk usage samples in the forecast window",
+			nil, nil,
+		),
+	}
+}
+
+func (c *forecastCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.predictedFull
+	ch <- c.storageGrowth
+	ch <- c.userGrowth
+	ch <- c.samples
+}
+
+func (c *forecastCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	storage, fetchedAt, err := getStorage(ctx, client)
+	if err != nil {
+		return err
+	}
+	// Per-user growth needs the admin-only statistics, the disk forecast
+	// doesn't, so it goes on without them
+	stats, statsFetchedAt, err := getStatistics(ctx, client)
+	if err != nil {
+		log.Printf("Skipping per-user growth forecast: %v", err)
+		stats = nil
+	} else if statsFetchedAt.Before(fetchedAt) {
+		fetchedAt = statsFetchedAt
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.loaded {
+		c.state = &forecastState{}
+		if _, err := c.store.Get("forecast", c.state); err != nil {
+			return err
+		}
+		c.loaded = true
+	}
+
+	// Concurrent scrapes can finish out of order, and values older than the
+	// last ones sampled would be recorded as newer
+	sampled := false
+	if !fetchedAt.Before(c.fetchedAt) {
+		sampled = c.sample(c.now().Unix(), storage, stats)
+		c.fetchedAt = fetchedAt
+	}
+	if sampled || !c.fitted {
+		c.fit()
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.samples, prometheus.GaugeValue, float64(len(c.state.DiskUse)))
+	if c.diskFit.ok {
+		predictedFull := math.Inf(1)
+		if c.diskFit.slope > 0 {
+			predictedFull = (float64(c.state.DiskCapacity) - c.diskFit.intercept) / c.diskFit.slope
+		}
+		ch <- prometheus.MustNewConstMetric(c.storageGrowth, prometheus.GaugeValue, c.diskFit.slope)
+		ch <- prometheus.MustNewConstMetric(c.predictedFull, prometheus.GaugeValue, predictedFull)
+	}
+	if stats != nil {
+		for _, usage := range stats.UsageByUser {
+			if fit := c.userFits[usage.UserID]; fit.ok {
+				labels := c.userLabel.labelValues(usage.UserID, usage.UserName)
+				ch <- prometheus.MustNewConstMetric(c.userGrowth, prometheus.GaugeValue, fit.slope, labels...)
+			}
+		}
+	}
+
+	if sampled {
+		if err := c.store.Set("forecast", c.state); err != nil {
+			return fmt.Errorf("saving forecast samples: %w", err)
+		}
+	}
+	return nil
+}
+
+// fit fits the disk and per-user windows
+func (c *forecastCollector) fit() {
+	c.diskFit = fitSamples(c.state.DiskUse)
+	c.userFits = make(map[string]forecastFit, len(c.state.Users))
+	for userID, samples := range c.state.Users {
+		c.userFits[userID] = fitSamples(samples)
+	}
+	c.fitted = true
+}
+
+func fitSamples(samples []forecastSample) forecastFit {
+	slope, intercept, ok := theilSen(samples)
+	return forecastFit{slope: slope, intercept: intercept, ok: ok}
+}
+
+// sample adds the current values to the window unless the last sample is
+// more recent than the sample interval, and drops samples older than the
+// window. Stats may be nil, leaving the user windows out. It reports whether
+// the window changed.
+func (c *forecastCollector) sample(now int64, storage *immich.StorageResponse, stats *immich.StatisticsResponse) bool {
+	if n := len(c.state.DiskUse); n > 0 && now-c.state.DiskUse[n-1].T < int64(c.sampleInterval.Seconds()) {
+		return false
+	}
+
+	cutoff := now - int64(c.window.Seconds())
+	c.state.DiskCapacity = storage.DiskUse + storage.DiskAvailable
+	c.state.DiskUse = appendSample(c.state.DiskUse, cutoff, forecastSample{T: now, V: float64(storage.DiskUse)})
+
+	// Without statistics the user windows are kept as they are
+	if stats == nil {
+		return true
+	}
+	// Users missing from the statistics were deleted and are dropped
+	users := make(map[string][]forecastSample, len(stats.UsageByUser))
+	for _, usage := range stats.UsageByUser {
+		users[usage.UserID] = appendSample(c.state.Users[usage.UserID], cutoff, forecastSample{T: now, V: float64(usage.Usage)})
+	}
+	c.state.Users = users
+	return true
+}
+
+// appendSample adds sample and drops the samples taken before cutoff
+func appendSample(samples []forecastSample, cutoff int64, sample forecastSample) []forecastSample {
+	kept := samples[:0]
+	for _, s := range samples {
+		if s.T >= cutoff {
+			kept = append(kept, s)
+		}
+	}
+	return append(kept, sample)
+}
+
+// theilSen fits a line through samples using the median of the pairwise
+// slopes, and the median of the resulting intercepts. The slope is per
+// second and the intercept is the value at Unix time 0.
+func theilSen(samples []forecastSample) (slope, intercept float64, ok bool) {
+	if len(samples) < minForecastSamples {
+		return 0, 0, false
+	}
+
+	// Times relative to the first sample keep the arithmetic precise
+	t0 := samples[0].T
+	var slopes []float64
+	for i := range samples {
+		for j := i + 1; j < len(samples); j++ {
+			dt := samples[j].T - samples[i].T
+			if dt == 0 {
+				continue
+			}
+			slopes = append(slopes, (samples[j].V-samples[i].V)/float64(dt))
+		}
+	}
+	if len(slopes) == 0 {
+		return 0, 0, false
+	}
+	slope = median(slopes)
+
+	intercepts := make([]float64, len(samples))
+	for i, s := range samples {
+		intercepts[i] = s.V - slope*float64(s.T-t0)
+	}
+	intercept = median(intercepts) - slope*float64(t0)
+	return slope, intercept, true
+}
+
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
@@ -0,0 +1,175 @@
+package collector
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+	"github.com/victorarias/immich-prometheus-exporter/internal/state"
+)
+
+func TestForecastCollector(t *testing.T) {
+	var diskUse, userUsage atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/server/storage":
+			// 500 bytes are reserved, so the disk is full at 9500 bytes used
+			json.NewEncoder(w).Encode(immich.StorageResponse{DiskSize: 10000, DiskUse: diskUse.Load(), DiskAvailable: 9500 - diskUse.Load()})
+		case "/api/server/statistics":
+			json.NewEncoder(w).Encode(immich.StatisticsResponse{UsageByUser: []immich.UserUsage{
+				{UserID: "u1", UserName: "alice", Usage: userUsage.Load()},
+			}})
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	store, err := state.Open(path)
+	if err != nil {
+		t.Fatalf("opening state: %v", err)
+	}
+	client := immich.NewClient(server.URL, "test-key")
+	start := time.Unix(1751371200, 0)
+	now := start
+	newCollector := func(store *state.Store) *ImmichCollector {
+		collector := New(client, onlyCollectors("forecast"), WithStateStore(store), WithForecastWindow(24*time.Hour, time.Hour))
+		collector.collectors["forecast"].(*forecastCollector).now = func() time.Time { return now }
+		return collector
+	}
+	collector := newCollector(store)
+
+	// Disk usage grows by 100 bytes an hour, with one outlier from a
+	// temporary import. Samples taken within the interval are skipped.
+	for i, use := range []int64{1000, 1100, 5000, 1300} {
+		now = start.Add(time.Duration(i) * time.Hour)
+		diskUse.Store(use)
+		userUsage.Store(int64(i) * 36)
+		testutil.CollectAndCount(collector)
+
+		now = now.Add(time.Minute)
+		diskUse.Store(9000)
+		testutil.CollectAndCount(collector)
+	}
+
+	// The window is restored from the state file after a restart
+	store, err = state.Open(path)
+	if err != nil {
+		t.Fatalf("reopening state: %v", err)
+	}
+	collector = newCollector(store)
+	now = start.Add(4 * time.Hour)
+	diskUse.Store(1400)
+	userUsage.Store(4 * 36)
+
+	// 8100 bytes left at 100 bytes an hour from 1400 at 4h
+	predictedFull := float64(start.Add(85 * time.Hour).Unix())
+	expected := `
+		# HELP immich_storage_forecast_samples Number of disk usage samples in the forecast window
+		# TYPE immich_storage_forecast_samples gauge
+		immich_storage_forecast_samples 5
+		# HELP immich_storage_predicted_full_timestamp_seconds Unix time the disk is predicted to be full at the current growth rate (+Inf when not growing)
+		# TYPE immich_storage_predicted_full_timestamp_seconds gauge
+		immich_storage_predicted_full_timestamp_seconds ` + formatFloat(predictedFull) + `
+		# HELP immich_user_growth_bytes_per_second Growth rate of storage used per user over the forecast window
+		# TYPE immich_user_growth_bytes_per_second gauge
+		immich_user_growth_bytes_per_second{user="alice"} 0.01
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"immich_storage_forecast_samples", "immich_storage_predicted_full_timestamp_seconds", "immich_user_growth_bytes_per_second"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
+
+func TestForecastCollector_WithoutStatistics(t *testing.T) {
+	var diskUse atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/server/storage":
+			json.NewEncoder(w).Encode(immich.StorageResponse{DiskSize: 10000, DiskUse: diskUse.Load(), DiskAvailable: 10000 - diskUse.Load()})
+		case "/api/server/statistics":
+			// The API key is not an admin's
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("forecast"), WithForecastWindow(24*time.Hour, time.Hour))
+	start := time.Unix(1751371200, 0)
+	now := start
+	collector.collectors["forecast"].(*forecastCollector).now = func() time.Time { return now }
+	for i := range 3 {
+		now = start.Add(time.Duration(i) * time.Hour)
+		diskUse.Store(1000 + int64(i)*100)
+		testutil.CollectAndCount(collector)
+	}
+
+	// 8800 bytes left at 100 bytes an hour from 1200 at 2h
+	predictedFull := float64(start.Add(90 * time.Hour).Unix())
+	expected := `
+		# HELP immich_scrape_collector_success Whether each collector succeeded (1=yes, 0=no)
+		# TYPE immich_scrape_collector_success gauge
+		immich_scrape_collector_success{collector="forecast"} 1
+		# HELP immich_storage_predicted_full_timestamp_seconds Unix time the disk is predicted to be full at the current growth rate (+Inf when not growing)
+		# TYPE immich_storage_predicted_full_timestamp_seconds gauge
+		immich_storage_predicted_full_timestamp_seconds ` + formatFloat(predictedFull) + `
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"immich_scrape_collector_success", "immich_storage_predicted_full_timestamp_seconds", "immich_user_growth_bytes_per_second"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
+
+func TestForecastCollector_SharedRequests(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("forecast", "statistics", "storage"))
+	testutil.CollectAndCount(collector)
+	if n := requests.Load(); n != 2 {
+		t.Errorf("expected one statistics and one storage request, got %d requests", n)
+	}
+}
+
+func TestForecastCollector_SampleLimit(t *testing.T) {
+	collector := New(immich.NewClient("http://localhost", "test-key"), onlyCollectors("forecast"),
+		WithForecastWindow(2000*time.Hour, time.Minute))
+	if interval := collector.collectors["forecast"].(*forecastCollector).sampleInterval; interval != 2*time.Hour {
+		t.Errorf("expected the sample interval widened to 2h, got %s", interval)
+	}
+}
+
+func TestTheilSen(t *testing.T) {
+	if _, _, ok := theilSen([]forecastSample{{T: 0, V: 1}, {T: 60, V: 2}}); ok {
+		t.Error("expected no fit with too few samples")
+	}
+
+	slope, intercept, ok := theilSen([]forecastSample{{T: 100, V: 10}, {T: 200, V: 20}, {T: 300, V: 1000}, {T: 400, V: 40}})
+	if !ok {
+		t.Fatal("expected a fit")
+	}
+	if slope != 0.1 || math.Abs(intercept) > 1e-9 {
+		t.Errorf("expected slope 0.1 and intercept 0 despite the outlier, got %v and %v", slope, intercept)
+	}
+
+	slope, _, _ = theilSen([]forecastSample{{T: 0, V: 5}, {T: 60, V: 5}, {T: 120, V: 5}})
+	if slope != 0 {
+		t.Errorf("expected zero slope for flat usage, got %v", slope)
+	}
+}
+
+func formatFloat(f float64) string {
+	data, _ := json.Marshal(f)
+	return string(data)
+}
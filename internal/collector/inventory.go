@@ -0,0 +1,344 @@
+package collector
+
+import (
+	"context"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+const (
+	// Default number of MIME types, extensions and camera makes exported with
+	// their own series, the rest are counted as "other"
+	defaultInventorySeriesLimit = 20
+	// Default interval between full scans, which also drop assets deleted
+	// permanently since the last one
+	defaultInventoryRescanInterval = 24 * time.Hour
+)
+
+// Extensions of camera RAW formats supported by Immich
+var rawExtensions = map[string]bool{
+	"3fr": true, "ari": true, "arw": true, "cap": true, "cin": true, "cr2": true,
+	"cr3": true, "crw": true, "dcr": true, "dng": true, "erf": true, "fff": true,
+	"iiq": true, "k25": true, "kdc": true, "mrw": true, "nef": true, "nrw": true,
+	"orf": true, "ori": true, "pef": true, "raf": true, "raw": true, "rw2": true,
+	"rwl": true, "sr2": true, "srf": true, "srw": true, "x3f": true,
+}
+
+// Upload windows for immich_inventory_assets_uploaded
+var uploadWindows = []struct {
+	label    string
+	duration time.Duration
+}{
+	{"1d", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+	{"365d", 365 * 24 * time.Hour},
+}
+
+func init() {
+	registerCollector("inventory", false, newInventoryCollector)
+}
+
+// inventoryAsset is what the inventory keeps of each asset
+type inventoryAsset struct {
+	mimeType   string
+	extension  string
+	cameraMake string
+	format     string
+	livePhoto  bool
+	uploadedAt time.Time
+}
+
+// inventoryCollector breaks down the assets of the API key's user by file
+// type, camera and upload date. Rather than paging through all assets on
+// every update, it keeps an inventory and only fetches assets changed since
+// the previous update, with a periodic full scan to drop deleted assets.
+// Full scans of large libraries outlast a scrape, so they run in the
+// background while updates serve the last complete inventory.
+type inventoryCollector struct {
+	now            func() time.Time
+	seriesLimit    int
+	rescanInterval time.Duration
+
+	// Updates are serialized since they share the inventory
+	mu           sync.Mutex
+	assets       map[string]inventoryAsset
+	lastFullScan time.Time
+	// Latest updatedAt seen, where the next incremental scan starts
+	updatedAfter string
+	scanning     bool
+	scanErr      error
+	// Tracks the background full scans, so tests can wait for them
+	scans sync.WaitGroup
+
+	byMimeType   *prometheus.Desc
+	byExtension  *prometheus.Desc
+	byCameraMake *prometheus.Desc
+	imageFormats *prometheus.Desc
+	livePhotos   *prometheus.Desc
+	uploaded     *prometheus.Desc
+	fullScan     *prometheus.Desc
+}
+
+func newInventoryCollector(opts options) Collector {
+	return &inventoryCollector{
+		now:            time.Now,
+		seriesLimit:    opts.inventorySeriesLimit,
+		rescanInterval: opts.inventoryRescanInterval,
+
+		byMimeType: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "inventory", "assets_by_mime_type"),
+			"Number of assets per MIME type, for the most common MIME types only",
+			[]string{"mime_type"}, nil,
+		),
+		byExtension: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "inventory", "assets_by_extension"),
+			"Number of assets per file extension, for the most common extensions only",
+			[]string{"extension"}, nil,
+		),
+		byCameraMake: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "inventory", "assets_by_camera_make"),
+			"Number of assets per camera make, for the most common makes only",
+			[]string{"make"}, nil,
+		),
+		imageFormats: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "inventory", "images_by_format"),
+			"Number of images by format (raw, jpeg or other)",
+			[]string{"format"}, nil,
+		),
+		livePhotos: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "inventory", "live_photos"),
+			"Number of live photos",
+			nil, nil,
+		),
+		uploaded: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "inventory", "assets_uploaded"),
+			"Number of assets uploaded within the last period",
+			[]string{"within"}, nil,
+		),
+		fullScan: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "inventory", "last_full_scan_timestamp_seconds"),
+			"Unix time of the last full scan of all assets",
+			nil, nil,
+		),
+	}
+}
+
+func (c *inventoryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.byMimeType
+	ch <- c.byExtension
+	ch <- c.byCameraMake
+	ch <- c.imageFormats
+	ch <- c.livePhotos
+	ch <- c.uploaded
+	ch <- c.fullScan
+}
+
+func (c *inventoryCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if !c.scanning && (c.assets == nil || now.Sub(c.lastFullScan) >= c.rescanInterval) {
+		c.scanning = true
+		c.scans.Add(1)
+		go c.scanAll(client, now)
+	}
+	// Nothing to report until the first full scan completes
+	if c.assets == nil {
+		return c.scanErr
+	}
+
+	if err := c.scanChanged(ctx, client); err != nil {
+		return err
+	}
+	c.collect(ch, now)
+	return nil
+}
+
+// scanAll rebuilds the inventory from all assets, bound by the rescan
+// interval rather than the scrape timeout. The previous inventory is kept if
+// the scan fails.
+func (c *inventoryCollector) scanAll(client *immich.Client, now time.Time) {
+	defer c.scans.Done()
+	ctx, cancel := context.WithTimeout(context.Background(), c.rescanInterval)
+	defer cancel()
+
+	assets := make(map[string]inventoryAsset)
+	var updatedAfter string
+	err := client.ForEachAsset(ctx, immich.MetadataSearchRequest{WithExif: true}, func(asset immich.Asset) {
+		assets[asset.ID] = newInventoryAsset(asset)
+		updatedAfter = laterTimestamp(updatedAfter, asset.UpdatedAt)
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scanning = false
+	c.scanErr = err
+	if err != nil {
+		log.Printf("Error scanning inventory: %v", err)
+		return
+	}
+
+	// Changes made during the scan are fetched again by the next
+	// incremental scan, since it starts from the scan's latest update
+	c.assets = assets
+	c.lastFullScan = now
+	c.updatedAfter = updatedAfter
+}
+
+// scanChanged applies the assets changed since the previous scan, including
+// trashed ones so they can be removed
+func (c *inventoryCollector) scanChanged(ctx context.Context, client *immich.Client) error {
+	req := immich.MetadataSearchRequest{WithExif: true, WithDeleted: true, UpdatedAfter: c.updatedAfter}
+	changed := make(map[string]*immich.Asset)
+	updatedAfter := c.updatedAfter
+	err := client.ForEachAsset(ctx, req, func(asset immich.Asset) {
+		changed[asset.ID] = &asset
+		updatedAfter = laterTimestamp(updatedAfter, asset.UpdatedAt)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Only applied once all pages were read, so a failed scan is retried
+	for id, asset := range changed {
+		if asset.IsTrashed {
+			delete(c.assets, id)
+		} else {
+			c.assets[id] = newInventoryAsset(*asset)
+		}
+	}
+	c.updatedAfter = updatedAfter
+	return nil
+}
+
+func (c *inventoryCollector) collect(ch chan<- prometheus.Metric, now time.Time) {
+	byMimeType := make(map[string]float64)
+	byExtension := make(map[string]float64)
+	byCameraMake := make(map[string]float64)
+	formats := map[string]float64{"raw": 0, "jpeg": 0, "other": 0}
+	uploaded := make([]float64, len(uploadWindows))
+	var livePhotos float64
+
+	for _, asset := range c.assets {
+		byMimeType[asset.mimeType]++
+		byExtension[asset.extension]++
+		byCameraMake[asset.cameraMake]++
+		if asset.format != "" {
+			formats[asset.format]++
+		}
+		if asset.livePhoto {
+			livePhotos++
+		}
+		for i, window := range uploadWindows {
+			if !asset.uploadedAt.IsZero() && now.Sub(asset.uploadedAt) <= window.duration {
+				uploaded[i]++
+			}
+		}
+	}
+
+	for mimeType, count := range topCounts(byMimeType, c.seriesLimit) {
+		ch <- prometheus.MustNewConstMetric(c.byMimeType, prometheus.GaugeValue, count, mimeType)
+	}
+	for extension, count := range topCounts(byExtension, c.seriesLimit) {
+		ch <- prometheus.MustNewConstMetric(c.byExtension, prometheus.GaugeValue, count, extension)
+	}
+	for cameraMake, count := range topCounts(byCameraMake, c.seriesLimit) {
+		ch <- prometheus.MustNewConstMetric(c.byCameraMake, prometheus.GaugeValue, count, cameraMake)
+	}
+	for format, count := range formats {
+		ch <- prometheus.MustNewConstMetric(c.imageFormats, prometheus.GaugeValue, count, format)
+	}
+	ch <- prometheus.MustNewConstMetric(c.livePhotos, prometheus.GaugeValue, livePhotos)
+	for i, window := range uploadWindows {
+		ch <- prometheus.MustNewConstMetric(c.uploaded, prometheus.GaugeValue, uploaded[i], window.label)
+	}
+	ch <- prometheus.MustNewConstMetric(c.fullScan, prometheus.GaugeValue, float64(c.lastFullScan.UnixNano())/1e9)
+}
+
+func newInventoryAsset(asset immich.Asset) inventoryAsset {
+	extension := strings.ToLower(strings.TrimPrefix(path.Ext(asset.OriginalFileName), "."))
+	if extension == "" {
+		extension = "unknown"
+	}
+	mimeType := asset.OriginalMimeType
+	if mimeType == "" {
+		mimeType = "unknown"
+	}
+	cameraMake := "unknown"
+	if asset.ExifInfo != nil && strings.TrimSpace(asset.ExifInfo.Make) != "" {
+		cameraMake = strings.TrimSpace(asset.ExifInfo.Make)
+	}
+
+	var format string
+	if asset.Type == "IMAGE" {
+		switch {
+		case rawExtensions[extension]:
+			format = "raw"
+		case mimeType == "image/jpeg":
+			format = "jpeg"
+		default:
+			format = "other"
+		}
+	}
+
+	uploadedAt, _ := time.Parse(time.RFC3339, asset.CreatedAt)
+	return inventoryAsset{
+		mimeType:   mimeType,
+		extension:  extension,
+		cameraMake: cameraMake,
+		format:     format,
+		livePhoto:  asset.LivePhotoVideoID != nil,
+		uploadedAt: uploadedAt,
+	}
+}
+
+// laterTimestamp returns the later of two RFC 3339 timestamps, ignoring b
+// when it cannot be parsed. An empty a counts as unset.
+func laterTimestamp(a, b string) string {
+	tb, err := time.Parse(time.RFC3339, b)
+	if err != nil {
+		return a
+	}
+	if ta, err := time.Parse(time.RFC3339, a); err == nil && !tb.After(ta) {
+		return a
+	}
+	return b
+}
+
+// topCounts keeps the limit largest counts, ties broken by key, and sums the
+// rest into "other" to keep cardinality bounded
+func topCounts(counts map[string]float64, limit int) map[string]float64 {
+	if len(counts) <= limit {
+		return counts
+	}
+
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	top := make(map[string]float64, limit+1)
+	for i, key := range keys {
+		if i < limit {
+			top[key] += counts[key]
+		} else {
+			top["other"] += counts[key]
+		}
+	}
+	return top
+}
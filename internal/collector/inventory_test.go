@@ -0,0 +1,170 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func TestInventoryCollector(t *testing.T) {
+	var mu sync.Mutex
+	var requests []immich.MetadataSearchRequest
+	var changed atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req immich.MetadataSearchRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+
+		if req.UpdatedAfter == "" {
+			w.Write([]byte(`{"assets":{"items":[
+				{"id":"a1","type":"IMAGE","originalFileName":"IMG_1.JPG","originalMimeType":"image/jpeg","createdAt":"2025-06-30T12:00:00.000Z","updatedAt":"2025-06-30T12:00:00.000Z","livePhotoVideoId":"v1","exifInfo":{"make":"Apple"}},
+				{"id":"a2","type":"IMAGE","originalFileName":"DSC_2.NEF","originalMimeType":"image/nef","createdAt":"2025-06-20T12:00:00.000Z","updatedAt":"2025-06-20T12:00:00.000Z","exifInfo":{"make":"NIKON CORPORATION"}},
+				{"id":"a3","type":"VIDEO","originalFileName":"clip.mp4","originalMimeType":"video/mp4","createdAt":"2024-01-01T12:00:00.000Z","updatedAt":"2024-01-01T12:00:00.000Z","exifInfo":null}
+			],"nextPage":null}}`))
+			return
+		}
+		if !changed.Load() {
+			w.Write([]byte(`{"assets":{"items":[],"nextPage":null}}`))
+			return
+		}
+		// Incremental scan: a3 was trashed and a4 uploaded
+		w.Write([]byte(`{"assets":{"items":[
+			{"id":"a3","type":"VIDEO","isTrashed":true,"updatedAt":"2025-07-01T12:30:00.000Z"},
+			{"id":"a4","type":"IMAGE","originalFileName":"IMG_4.heic","originalMimeType":"image/heic","createdAt":"2025-07-01T12:10:00.000Z","updatedAt":"2025-07-01T12:10:00.000Z","exifInfo":{"make":"Apple"}}
+		],"nextPage":null}}`))
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("inventory"), WithInventorySeriesLimit(1))
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	inventory := collector.collectors["inventory"].(*inventoryCollector)
+	inventory.now = func() time.Time { return now }
+
+	// The first update starts the full scan in the background
+	if n := testutil.CollectAndCount(collector, "immich_inventory_live_photos"); n != 0 {
+		t.Errorf("expected no inventory before the first full scan, got %d series", n)
+	}
+	inventory.scans.Wait()
+
+	expected := `
+		# HELP immich_inventory_assets_by_camera_make Number of assets per camera make, for the most common makes only
+		# TYPE immich_inventory_assets_by_camera_make gauge
+		immich_inventory_assets_by_camera_make{make="Apple"} 1
+		immich_inventory_assets_by_camera_make{make="other"} 2
+		# HELP immich_inventory_assets_by_mime_type Number of assets per MIME type, for the most common MIME types only
+		# TYPE immich_inventory_assets_by_mime_type gauge
+		immich_inventory_assets_by_mime_type{mime_type="image/jpeg"} 1
+		immich_inventory_assets_by_mime_type{mime_type="other"} 2
+		# HELP immich_inventory_assets_uploaded Number of assets uploaded within the last period
+		# TYPE immich_inventory_assets_uploaded gauge
+		immich_inventory_assets_uploaded{within="1d"} 1
+		immich_inventory_assets_uploaded{within="30d"} 2
+		immich_inventory_assets_uploaded{within="365d"} 2
+		immich_inventory_assets_uploaded{within="7d"} 1
+		# HELP immich_inventory_images_by_format Number of images by format (raw, jpeg or other)
+		# TYPE immich_inventory_images_by_format gauge
+		immich_inventory_images_by_format{format="jpeg"} 1
+		immich_inventory_images_by_format{format="other"} 0
+		immich_inventory_images_by_format{format="raw"} 1
+		# HELP immich_inventory_live_photos Number of live photos
+		# TYPE immich_inventory_live_photos gauge
+		immich_inventory_live_photos 1
+	`
+	metrics := []string{
+		"immich_inventory_assets_by_camera_make", "immich_inventory_assets_by_mime_type",
+		"immich_inventory_assets_uploaded", "immich_inventory_images_by_format", "immich_inventory_live_photos",
+	}
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), metrics...); err != nil {
+		t.Errorf("unexpected metric value after full scan: %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	changed.Store(true)
+	expected = `
+		# HELP immich_inventory_assets_by_extension Number of assets per file extension, for the most common extensions only
+		# TYPE immich_inventory_assets_by_extension gauge
+		immich_inventory_assets_by_extension{extension="heic"} 1
+		immich_inventory_assets_by_extension{extension="other"} 2
+		# HELP immich_inventory_images_by_format Number of images by format (raw, jpeg or other)
+		# TYPE immich_inventory_images_by_format gauge
+		immich_inventory_images_by_format{format="jpeg"} 1
+		immich_inventory_images_by_format{format="other"} 1
+		immich_inventory_images_by_format{format="raw"} 1
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"immich_inventory_assets_by_extension", "immich_inventory_images_by_format"); err != nil {
+		t.Errorf("unexpected metric value after incremental scan: %v", err)
+	}
+
+	if len(requests) != 3 {
+		t.Fatalf("expected 3 searches, got %d", len(requests))
+	}
+	if !requests[1].WithDeleted || requests[1].UpdatedAfter != "2025-06-30T12:00:00.000Z" {
+		t.Errorf("expected incremental search from the latest update, got %+v", requests[1])
+	}
+
+	// A full scan replaces the inventory once the rescan interval passed
+	now = now.Add(defaultInventoryRescanInterval)
+	testutil.CollectAndCount(collector)
+	inventory.scans.Wait()
+	fullScans := 0
+	for _, req := range requests {
+		if req.UpdatedAfter == "" && !req.WithDeleted {
+			fullScans++
+		}
+	}
+	if fullScans != 2 {
+		t.Errorf("expected a second full scan, got %d full scans in %+v", fullScans, requests)
+	}
+}
+
+func TestInventoryCollector_SlowFullScan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req immich.MetadataSearchRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.UpdatedAfter == "" {
+			// Paging through a large library takes longer than a scrape
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte(`{"assets":{"items":[{"id":"a1","type":"IMAGE","originalFileName":"IMG_1.JPG","updatedAt":"2025-06-30T12:00:00.000Z"}],"nextPage":null}}`))
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("inventory"))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	testutil.CollectAndCount(collector.WithContext(ctx))
+	collector.collectors["inventory"].(*inventoryCollector).scans.Wait()
+
+	expected := `
+		# HELP immich_inventory_live_photos Number of live photos
+		# TYPE immich_inventory_live_photos gauge
+		immich_inventory_live_photos 0
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "immich_inventory_live_photos"); err != nil {
+		t.Errorf("expected the scan to outlast the scrape: %v", err)
+	}
+}
+
+func TestTopCounts(t *testing.T) {
+	counts := map[string]float64{"a": 5, "b": 3, "c": 3, "d": 1}
+
+	top := topCounts(counts, 2)
+	if len(top) != 3 || top["a"] != 5 || top["b"] != 3 || top["other"] != 4 {
+		t.Errorf("unexpected top counts: %v", top)
+	}
+	if top := topCounts(counts, 10); len(top) != 4 {
+		t.Errorf("expected counts under the limit unchanged, got %v", top)
+	}
+}
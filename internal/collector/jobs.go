@@ -0,0 +1,265 @@
+package collector
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+// Time constant of the moving average of the queue reduction rate, so the
+// drain estimate follows recent minutes rather than single polls
+const reductionAveragingWindow = 5 * time.Minute
+
+// Default time without progress before a busy queue counts as stalled
+const defaultQueueStallWindow = time.Hour
+
+func init() {
+	registerCollector("jobs", true, newJobsCollector)
+}
+
+// queueProgress is what the jobs collector remembers of a queue between
+// updates to estimate how fast it shrinks
+type queueProgress struct {
+	counts    immich.JobCounts
+	updatedAt time.Time
+
+	reduced float64
+	failed  float64
+	// Moving average of the net decrease in unfinished jobs per second,
+	// negative until known
+	reductionRate float64
+	// Last time the active, waiting or failed counts changed, or the queue
+	// had nothing to do
+	lastProgress time.Time
+}
+
+// jobsCollector exports per-queue job counts from /api/jobs. Immich removes
+// completed jobs from its queues, so how fast a queue shrinks is derived from
+// how the counts change between updates.
+type jobsCollector struct {
+	now func() time.Time
+	// Time without progress before a busy queue counts as stalled, by queue
+	// with a default
+	stallWindow  time.Duration
+	stallWindows map[string]time.Duration
+
+	// Updates are serialized since they share the queue history
+	mu     sync.Mutex
+	queues map[string]*queueProgress
+
+	jobActive    *prometheus.Desc
+	jobWaiting   *prometheus.Desc
+	jobFailed    *prometheus.Desc
+	jobDelayed   *prometheus.Desc
+	jobPaused    *prometheus.Desc
+	jobCompleted *prometheus.Desc
+	queueActive  *prometheus.Desc
+	queuePaused  *prometheus.Desc
+
+	queueNetReduction *prometheus.Desc
+	jobFailedTotal    *prometheus.Desc
+	queueDrainSeconds *prometheus.Desc
+	queueStalled      *prometheus.Desc
+	sinceProgress     *prometheus.Desc
+}
+
+func newJobsCollector(opts options) Collector {
+	return &jobsCollector{
+		now:          time.Now,
+		stallWindow:  opts.queueStallWindow,
+		stallWindows: opts.queueStallWindows,
+		queues:       make(map[string]*queueProgress),
+
+		jobActive: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "job", "active"),
+			"Number of active jobs",
+			[]string{"queue"}, nil,
+		),
+		jobWaiting: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "job", "waiting"),
+			"Number of waiting jobs",
+			[]string{"queue"}, nil,
+		),
+		jobFailed: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "job", "failed"),
+			"Number of failed jobs",
+			[]string{"queue"}, nil,
+		),
+		jobDelayed: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "job", "delayed"),
+			"Number of delayed jobs",
+			[]string{"queue"}, nil,
+		),
+		jobPaused: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "job", "paused"),
+			"Number of paused jobs",
+			[]string{"queue"}, nil,
+		),
+		jobCompleted: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "job", "completed"),
+			"Number of completed jobs",
+			[]string{"queue"}, nil,
+		),
+		queueActive: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue", "active"),
+			"Whether queue is active (1=yes, 0=no)",
+			[]string{"queue"}, nil,
+		),
+		queuePaused: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue", "paused"),
+			"Whether queue is paused (1=yes, 0=no)",
+			[]string{"queue"}, nil,
+		),
+		queueNetReduction: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue", "net_reduction_total"),
+			"Net decrease in unfinished jobs between updates, not counting jobs that failed or were queued in the meantime",
+			[]string{"queue"}, nil,
+		),
+		jobFailedTotal: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "job", "failed_total"),
+			"Estimated number of jobs failed, derived from queue counts",
+			[]string{"queue"}, nil,
+		),
+		queueDrainSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue", "drain_seconds"),
+			"Estimated seconds until waiting jobs are gone at the recent net reduction rate (+Inf while the queue is not shrinking)",
+			[]string{"queue"}, nil,
+		),
+		queueStalled: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue", "stalled"),
+			"Whether a queue with active jobs kept the same job counts for its stall window (1=yes, 0=no)",
+			[]string{"queue"}, nil,
+		),
+		sinceProgress: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue", "seconds_since_progress"),
+			"Seconds since the active, waiting or failed job counts of a queue last changed, or it was idle",
+			[]string{"queue"}, nil,
+		),
+	}
+}
+
+func (c *jobsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.jobActive
+	ch <- c.jobWaiting
+	ch <- c.jobFailed
+	ch <- c.jobDelayed
+	ch <- c.jobPaused
+	ch <- c.jobCompleted
+	ch <- c.queueActive
+	ch <- c.queuePaused
+	ch <- c.queueNetReduction
+	ch <- c.jobFailedTotal
+	ch <- c.queueDrainSeconds
+	ch <- c.queueStalled
+	ch <- c.sinceProgress
+}
+
+func (c *jobsCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	// Fetching under the lock keeps concurrent scrapes from applying an older
+	// snapshot after a newer one, which would count the jobs between them twice
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	jobs, err := client.GetJobs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for queueName, queue := range jobs {
+		ch <- prometheus.MustNewConstMetric(c.jobActive, prometheus.GaugeValue, float64(queue.JobCounts.Active), queueName)
+		ch <- prometheus.MustNewConstMetric(c.jobWaiting, prometheus.GaugeValue, float64(queue.JobCounts.Waiting), queueName)
+		ch <- prometheus.MustNewConstMetric(c.jobFailed, prometheus.GaugeValue, float64(queue.JobCounts.Failed), queueName)
+		ch <- prometheus.MustNewConstMetric(c.jobDelayed, prometheus.GaugeValue, float64(queue.JobCounts.Delayed), queueName)
+		ch <- prometheus.MustNewConstMetric(c.jobPaused, prometheus.GaugeValue, float64(queue.JobCounts.Paused), queueName)
+		ch <- prometheus.MustNewConstMetric(c.jobCompleted, prometheus.GaugeValue, float64(queue.JobCounts.Completed), queueName)
+		ch <- prometheus.MustNewConstMetric(c.queueActive, prometheus.GaugeValue, boolToFloat(queue.QueueStatus.IsActive), queueName)
+		ch <- prometheus.MustNewConstMetric(c.queuePaused, prometheus.GaugeValue, boolToFloat(queue.QueueStatus.IsPaused), queueName)
+	}
+
+	now := c.now()
+	for queueName, queue := range jobs {
+		progress, seen := c.queues[queueName]
+		if !seen {
+			// Progress before the first update is unknown, so the stall
+			// window starts now
+			progress = &queueProgress{reductionRate: -1, lastProgress: now}
+			c.queues[queueName] = progress
+		} else {
+			// A queue working through jobs changes its counts even when new
+			// jobs keep it from shrinking, so only frozen counts are a stall
+			if progress.counts.Active != queue.JobCounts.Active || progress.counts.Waiting != queue.JobCounts.Waiting ||
+				progress.counts.Failed != queue.JobCounts.Failed {
+				progress.lastProgress = now
+			}
+			progress.observe(queue.JobCounts, now.Sub(progress.updatedAt))
+		}
+		if queue.JobCounts.Active == 0 && queue.JobCounts.Waiting == 0 {
+			progress.lastProgress = now
+		}
+		progress.counts = queue.JobCounts
+		progress.updatedAt = now
+
+		sinceProgress := now.Sub(progress.lastProgress)
+		stalled := queue.QueueStatus.IsActive && queue.JobCounts.Active > 0 && sinceProgress >= c.stallWindowFor(queueName)
+		ch <- prometheus.MustNewConstMetric(c.queueStalled, prometheus.GaugeValue, boolToFloat(stalled), queueName)
+		ch <- prometheus.MustNewConstMetric(c.sinceProgress, prometheus.GaugeValue, sinceProgress.Seconds(), queueName)
+
+		ch <- prometheus.MustNewConstMetric(c.queueNetReduction, prometheus.CounterValue, progress.reduced, queueName)
+		ch <- prometheus.MustNewConstMetric(c.jobFailedTotal, prometheus.CounterValue, progress.failed, queueName)
+		if drain, ok := progress.drainSeconds(); ok {
+			ch <- prometheus.MustNewConstMetric(c.queueDrainSeconds, prometheus.GaugeValue, drain, queueName)
+		}
+	}
+	return nil
+}
+
+func (c *jobsCollector) stallWindowFor(queue string) time.Duration {
+	if window, ok := c.stallWindows[queue]; ok {
+		return window
+	}
+	return c.stallWindow
+}
+
+// observe records the failed jobs and the net decrease in unfinished jobs
+// since the previous counts.
+// Immich drops finished jobs from its queues and its completed count usually
+// stays 0, so jobs processed while as many new ones are queued cancel out: a
+// busy queue that keeps growing shows no reduction.
+func (p *queueProgress) observe(counts immich.JobCounts, elapsed time.Duration) {
+	prev := p.counts
+	failed := max(counts.Failed-prev.Failed, 0)
+	unfinished := func(c immich.JobCounts) int { return c.Waiting + c.Active + c.Delayed }
+	reduced := max(unfinished(prev)-unfinished(counts)-failed, 0)
+	p.reduced += float64(reduced)
+	p.failed += float64(failed)
+
+	if elapsed <= 0 {
+		return
+	}
+	rate := float64(reduced) / elapsed.Seconds()
+	if p.reductionRate < 0 {
+		p.reductionRate = rate
+		return
+	}
+	alpha := 1 - math.Exp(-elapsed.Seconds()/reductionAveragingWindow.Seconds())
+	p.reductionRate += alpha * (rate - p.reductionRate)
+}
+
+// drainSeconds estimates how long the waiting jobs take to be gone at the
+// recent net reduction rate, once it is known
+func (p *queueProgress) drainSeconds() (float64, bool) {
+	switch {
+	case p.reductionRate < 0:
+		return 0, false
+	case p.counts.Waiting == 0:
+		return 0, true
+	case p.reductionRate == 0:
+		return math.Inf(1), true
+	default:
+		return float64(p.counts.Waiting) / p.reductionRate, true
+	}
+}
@@ -0,0 +1,235 @@
+package collector
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func TestJobsCollector_NetReduction(t *testing.T) {
+	var counts atomic.Value
+	counts.Store(immich.JobCounts{Active: 2, Waiting: 100, Failed: 1})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(immich.JobsResponse{
+			"ocr": {JobCounts: counts.Load().(immich.JobCounts), QueueStatus: immich.QueueStatus{IsActive: true}},
+		})
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("jobs"))
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	collector.collectors["jobs"].(*jobsCollector).now = func() time.Time { return now }
+
+	// No drain estimate before the reduction rate is known
+	testutil.CollectAndCount(collector)
+	if n := testutil.CollectAndCount(collector, "immich_queue_drain_seconds"); n != 0 {
+		t.Errorf("expected no drain estimate after a single update, got %d series", n)
+	}
+
+	// 12 jobs left the queue in a minute, 2 of them failed
+	now = now.Add(time.Minute)
+	counts.Store(immich.JobCounts{Active: 2, Waiting: 88, Failed: 3})
+
+	expected := `
+		# HELP immich_job_failed_total Estimated number of jobs failed, derived from queue counts
+		# TYPE immich_job_failed_total counter
+		immich_job_failed_total{queue="ocr"} 2
+		# HELP immich_queue_drain_seconds Estimated seconds until waiting jobs are gone at the recent net reduction rate (+Inf while the queue is not shrinking)
+		# TYPE immich_queue_drain_seconds gauge
+		immich_queue_drain_seconds{queue="ocr"} 528
+		# HELP immich_queue_net_reduction_total Net decrease in unfinished jobs between updates, not counting jobs that failed or were queued in the meantime
+		# TYPE immich_queue_net_reduction_total counter
+		immich_queue_net_reduction_total{queue="ocr"} 10
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"immich_job_failed_total", "immich_queue_net_reduction_total", "immich_queue_drain_seconds"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
+
+func TestJobsCollector_GrowingQueue(t *testing.T) {
+	var waiting atomic.Int64
+	waiting.Store(500)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(immich.JobsResponse{
+			"metadataExtraction": {
+				JobCounts:   immich.JobCounts{Active: 4, Waiting: int(waiting.Load())},
+				QueueStatus: immich.QueueStatus{IsActive: true},
+			},
+		})
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("jobs"))
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	collector.collectors["jobs"].(*jobsCollector).now = func() time.Time { return now }
+
+	// A library scan queues jobs faster than the busy queue works them off
+	for range 13 {
+		testutil.CollectAndCount(collector)
+		now = now.Add(10 * time.Minute)
+		waiting.Add(100)
+	}
+
+	expected := `
+		# HELP immich_queue_drain_seconds Estimated seconds until waiting jobs are gone at the recent net reduction rate (+Inf while the queue is not shrinking)
+		# TYPE immich_queue_drain_seconds gauge
+		immich_queue_drain_seconds{queue="metadataExtraction"} +Inf
+		# HELP immich_queue_net_reduction_total Net decrease in unfinished jobs between updates, not counting jobs that failed or were queued in the meantime
+		# TYPE immich_queue_net_reduction_total counter
+		immich_queue_net_reduction_total{queue="metadataExtraction"} 0
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"immich_queue_drain_seconds", "immich_queue_net_reduction_total"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+
+	// The queue is busy, not stalled, although it does not shrink
+	expected = `
+		# HELP immich_queue_stalled Whether a queue with active jobs kept the same job counts for its stall window (1=yes, 0=no)
+		# TYPE immich_queue_stalled gauge
+		immich_queue_stalled{queue="metadataExtraction"} 0
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "immich_queue_stalled"); err != nil {
+		t.Errorf("unexpected stall state: %v", err)
+	}
+}
+
+func TestQueueProgress_Observe(t *testing.T) {
+	progress := &queueProgress{reductionRate: -1, counts: immich.JobCounts{Waiting: 50, Active: 4, Failed: 1}}
+
+	// Failed jobs leave the queue but don't count as a reduction
+	progress.observe(immich.JobCounts{Waiting: 20, Active: 4, Failed: 1 + 6}, time.Minute)
+	progress.counts = immich.JobCounts{Waiting: 20, Active: 4, Failed: 7}
+	if progress.reduced != 24 || progress.failed != 6 {
+		t.Errorf("expected a reduction of 24 and 6 failed jobs, got %v and %v", progress.reduced, progress.failed)
+	}
+	if progress.reductionRate != 0.4 {
+		t.Errorf("expected first reduction rate of 0.4/s, got %v", progress.reductionRate)
+	}
+
+	// Jobs queued as fast as others finish cancel out, and the rate decays
+	progress.observe(immich.JobCounts{Waiting: 20, Active: 4, Failed: 7}, time.Minute)
+	if progress.reductionRate <= 0 || progress.reductionRate >= 0.4 {
+		t.Errorf("expected decaying reduction rate, got %v", progress.reductionRate)
+	}
+
+	progress.reductionRate = 0
+	if drain, ok := progress.drainSeconds(); !ok || !math.IsInf(drain, 1) {
+		t.Errorf("expected +Inf drain time for a stalled queue, got %v", drain)
+	}
+	progress.counts.Waiting = 0
+	if drain, ok := progress.drainSeconds(); !ok || drain != 0 {
+		t.Errorf("expected zero drain time for an empty queue, got %v", drain)
+	}
+}
+
+func TestJobsCollector_Stalled(t *testing.T) {
+	var counts atomic.Value
+	counts.Store(immich.JobCounts{Active: 1, Waiting: 40})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(immich.JobsResponse{
+			"ocr":             {JobCounts: counts.Load().(immich.JobCounts), QueueStatus: immich.QueueStatus{IsActive: true}},
+			"videoConversion": {JobCounts: counts.Load().(immich.JobCounts), QueueStatus: immich.QueueStatus{IsActive: true}},
+			"sidecar":         {JobCounts: immich.JobCounts{}, QueueStatus: immich.QueueStatus{}},
+		})
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("jobs"),
+		WithQueueStallWindow(time.Hour, map[string]time.Duration{"videoConversion": 6 * time.Hour}))
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	collector.collectors["jobs"].(*jobsCollector).now = func() time.Time { return now }
+
+	testutil.CollectAndCount(collector)
+	now = now.Add(2 * time.Hour)
+
+	expected := `
+		# HELP immich_queue_seconds_since_progress Seconds since the active, waiting or failed job counts of a queue last changed, or it was idle
+		# TYPE immich_queue_seconds_since_progress gauge
+		immich_queue_seconds_since_progress{queue="ocr"} 7200
+		immich_queue_seconds_since_progress{queue="sidecar"} 0
+		immich_queue_seconds_since_progress{queue="videoConversion"} 7200
+		# HELP immich_queue_stalled Whether a queue with active jobs kept the same job counts for its stall window (1=yes, 0=no)
+		# TYPE immich_queue_stalled gauge
+		immich_queue_stalled{queue="ocr"} 1
+		immich_queue_stalled{queue="sidecar"} 0
+		immich_queue_stalled{queue="videoConversion"} 0
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"immich_queue_seconds_since_progress", "immich_queue_stalled"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+
+	// Changing counts reset the progress clock
+	now = now.Add(time.Minute)
+	counts.Store(immich.JobCounts{Active: 1, Waiting: 30})
+	expected = `
+		# HELP immich_queue_stalled Whether a queue with active jobs kept the same job counts for its stall window (1=yes, 0=no)
+		# TYPE immich_queue_stalled gauge
+		immich_queue_stalled{queue="ocr"} 0
+		immich_queue_stalled{queue="sidecar"} 0
+		immich_queue_stalled{queue="videoConversion"} 0
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "immich_queue_stalled"); err != nil {
+		t.Errorf("unexpected metric value after progress: %v", err)
+	}
+}
+
+func TestJobsCollector_ConcurrentScrapes(t *testing.T) {
+	var requests atomic.Int32
+	fetching, release := make(chan struct{}), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		waiting := 80
+		switch requests.Add(1) {
+		case 1:
+			waiting = 100
+		case 2:
+			// A slow scrape fetches the snapshot between the other two
+			close(fetching)
+			<-release
+			waiting = 90
+		}
+		json.NewEncoder(w).Encode(immich.JobsResponse{
+			"ocr": {JobCounts: immich.JobCounts{Active: 2, Waiting: waiting}, QueueStatus: immich.QueueStatus{IsActive: true}},
+		})
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("jobs"))
+	testutil.CollectAndCount(collector)
+
+	slow := make(chan struct{})
+	go func() {
+		testutil.CollectAndCount(collector)
+		close(slow)
+	}()
+	<-fetching
+	fast := make(chan struct{})
+	go func() {
+		testutil.CollectAndCount(collector)
+		close(fast)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	<-slow
+	<-fast
+
+	// 20 jobs left the queue, counted once despite the overlap
+	expected := `
+		# HELP immich_queue_net_reduction_total Net decrease in unfinished jobs between updates, not counting jobs that failed or were queued in the meantime
+		# TYPE immich_queue_net_reduction_total counter
+		immich_queue_net_reduction_total{queue="ocr"} 20
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "immich_queue_net_reduction_total"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
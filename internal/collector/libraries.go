@@ -0,0 +1,81 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func init() {
+	registerCollector("libraries", false, newLibrariesCollector)
+}
+
+// librariesCollector exports the scan health of external libraries, which
+// can silently stop refreshing
+type librariesCollector struct {
+	now func() time.Time
+
+	libraryAssets       *prometheus.Desc
+	libraryImportPaths  *prometheus.Desc
+	libraryLastRefresh  *prometheus.Desc
+	librarySinceRefresh *prometheus.Desc
+}
+
+func newLibrariesCollector(opts options) Collector {
+	labels := []string{"library_id", "library"}
+	return &librariesCollector{
+		now: time.Now,
+
+		libraryAssets: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "external_library", "assets"),
+			"Number of assets per external library",
+			labels, nil,
+		),
+		libraryImportPaths: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "external_library", "import_paths"),
+			"Number of import paths per external library",
+			labels, nil,
+		),
+		libraryLastRefresh: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "external_library", "last_refresh_timestamp_seconds"),
+			"Unix time of the last scan per external library",
+			labels, nil,
+		),
+		librarySinceRefresh: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "external_library", "seconds_since_refresh"),
+			"Seconds since the last scan per external library",
+			labels, nil,
+		),
+	}
+}
+
+func (c *librariesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.libraryAssets
+	ch <- c.libraryImportPaths
+	ch <- c.libraryLastRefresh
+	ch <- c.librarySinceRefresh
+}
+
+func (c *librariesCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	libraries, err := client.GetLibraries(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := float64(c.now().UnixNano()) / 1e9
+	for _, library := range libraries {
+		ch <- prometheus.MustNewConstMetric(c.libraryAssets, prometheus.GaugeValue, float64(library.AssetCount), library.ID, library.Name)
+		ch <- prometheus.MustNewConstMetric(c.libraryImportPaths, prometheus.GaugeValue, float64(len(library.ImportPaths)), library.ID, library.Name)
+
+		// Libraries that were never scanned have no refresh time
+		refreshedAt, err := parseTimestamp(library.RefreshedAt)
+		if err != nil {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.libraryLastRefresh, prometheus.GaugeValue, refreshedAt, library.ID, library.Name)
+		ch <- prometheus.MustNewConstMetric(c.librarySinceRefresh, prometheus.GaugeValue, now-refreshedAt, library.ID, library.Name)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func TestLibrariesCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"id":"l1","name":"NAS","importPaths":["/mnt/photos","/mnt/scans"],"assetCount":1200,"refreshedAt":"2025-07-01T12:00:00.000Z"},
+			{"id":"l2","name":"New","importPaths":[],"assetCount":0,"refreshedAt":null}
+		]`))
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("libraries"))
+	collector.collectors["libraries"].(*librariesCollector).now = func() time.Time {
+		return time.Date(2025, 7, 2, 12, 0, 0, 0, time.UTC)
+	}
+
+	expected := `
+		# HELP immich_external_library_assets Number of assets per external library
+		# TYPE immich_external_library_assets gauge
+		immich_external_library_assets{library="NAS",library_id="l1"} 1200
+		immich_external_library_assets{library="New",library_id="l2"} 0
+		# HELP immich_external_library_import_paths Number of import paths per external library
+		# TYPE immich_external_library_import_paths gauge
+		immich_external_library_import_paths{library="NAS",library_id="l1"} 2
+		immich_external_library_import_paths{library="New",library_id="l2"} 0
+		# HELP immich_external_library_seconds_since_refresh Seconds since the last scan per external library
+		# TYPE immich_external_library_seconds_since_refresh gauge
+		immich_external_library_seconds_since_refresh{library="NAS",library_id="l1"} 86400
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"immich_external_library_assets", "immich_external_library_import_paths", "immich_external_library_seconds_since_refresh"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
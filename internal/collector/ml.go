@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+// Timeout for pinging the machine learning service when the scrape has none
+const machineLearningTimeout = 10 * time.Second
+
+func init() {
+	registerCollector("machine_learning", false, newMachineLearningCollector)
+}
+
+// machineLearningCollector probes the machine learning service directly.
+// Smart search and face detection silently degrade while it is down, which
+// the server API does not report.
+type machineLearningCollector struct {
+	client *immich.MachineLearningClient
+
+	up           *prometheus.Desc
+	pingDuration *prometheus.Desc
+}
+
+func newMachineLearningCollector(opts options) Collector {
+	c := &machineLearningCollector{
+		up: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "ml", "up"),
+			"Whether the machine learning service answers pings (1=yes, 0=no)",
+			nil, nil,
+		),
+		pingDuration: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "ml", "ping_duration_seconds"),
+			"Time taken by the machine learning service to answer a ping",
+			nil, nil,
+		),
+	}
+	if opts.machineLearningURL != "" {
+		c.client = immich.NewMachineLearningClient(opts.machineLearningURL, machineLearningTimeout)
+	}
+	return c
+}
+
+func (c *machineLearningCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.up
+	ch <- c.pingDuration
+}
+
+// Update reports an unreachable service as immich_ml_up 0 rather than an
+// error, so the collector only fails when it is misconfigured
+func (c *machineLearningCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	if c.client == nil {
+		return errors.New("no machine learning URL configured")
+	}
+
+	start := time.Now()
+	err := c.client.Ping(ctx)
+	duration := time.Since(start).Seconds()
+	if err != nil {
+		log.Printf("Machine learning service is down: %v", err)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, boolToFloat(err == nil))
+	ch <- prometheus.MustNewConstMetric(c.pingDuration, prometheus.GaugeValue, duration)
+	return nil
+}
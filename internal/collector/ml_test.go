@@ -0,0 +1,63 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func TestMachineLearningCollector(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		up     string
+	}{
+		{name: "up", status: http.StatusOK, up: "1"},
+		{name: "down", status: http.StatusInternalServerError, up: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ml := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/ping" {
+					t.Errorf("unexpected path %s", r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer ml.Close()
+
+			collector := New(immich.NewClient("http://immich.invalid", "test-key"),
+				onlyCollectors("machine_learning"), WithMachineLearningURL(ml.URL))
+
+			expected := `
+				# HELP immich_ml_up Whether the machine learning service answers pings (1=yes, 0=no)
+				# TYPE immich_ml_up gauge
+				immich_ml_up ` + tt.up + `
+				# HELP immich_scrape_collector_success Whether each collector succeeded (1=yes, 0=no)
+				# TYPE immich_scrape_collector_success gauge
+				immich_scrape_collector_success{collector="machine_learning"} 1
+			`
+			if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+				"immich_ml_up", "immich_scrape_collector_success"); err != nil {
+				t.Errorf("unexpected metric value: %v", err)
+			}
+		})
+	}
+}
+
+func TestMachineLearningCollector_NoURL(t *testing.T) {
+	collector := New(immich.NewClient("http://immich.invalid", "test-key"), onlyCollectors("machine_learning"))
+
+	expected := `
+		# HELP immich_scrape_collector_success Whether each collector succeeded (1=yes, 0=no)
+		# TYPE immich_scrape_collector_success gauge
+		immich_scrape_collector_success{collector="machine_learning"} 0
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "immich_scrape_collector_success"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
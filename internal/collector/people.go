@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+// Page size when listing people
+const peoplePageSize = 1000
+
+func init() {
+	registerCollector("people", false, newPeopleCollector)
+}
+
+// peopleCollector exports the outcome of face detection and recognition:
+// recognized people and how many assets have detected faces. It pages through
+// all people and assets of the API key's user, so prefer running it with
+// background polling.
+type peopleCollector struct {
+	people             *prometheus.Desc
+	peopleHidden       *prometheus.Desc
+	assetsWithFaces    *prometheus.Desc
+	assetsWithoutFaces *prometheus.Desc
+}
+
+func newPeopleCollector(opts options) Collector {
+	return &peopleCollector{
+		people: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "people"),
+			"Number of recognized people by naming (named or unnamed)",
+			[]string{"naming"}, nil,
+		),
+		peopleHidden: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "people", "hidden"),
+			"Number of hidden people",
+			nil, nil,
+		),
+		assetsWithFaces: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "assets", "with_faces"),
+			"Number of assets with at least one detected face",
+			nil, nil,
+		),
+		assetsWithoutFaces: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "assets", "without_faces"),
+			"Number of assets without detected faces",
+			nil, nil,
+		),
+	}
+}
+
+func (c *peopleCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.people
+	ch <- c.peopleHidden
+	ch <- c.assetsWithFaces
+	ch <- c.assetsWithoutFaces
+}
+
+func (c *peopleCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	var named, unnamed float64
+	var hidden int64
+	for page := 1; ; page++ {
+		resp, err := client.GetPeople(ctx, page, peoplePageSize)
+		if err != nil {
+			return err
+		}
+		hidden = resp.Hidden
+		for _, person := range resp.People {
+			if person.Name != "" {
+				named++
+			} else {
+				unnamed++
+			}
+		}
+		if !resp.HasNextPage {
+			break
+		}
+	}
+
+	var withFaces, withoutFaces float64
+	err := client.ForEachAsset(ctx, immich.MetadataSearchRequest{WithPeople: true}, func(asset immich.Asset) {
+		if len(asset.People) > 0 || len(asset.UnassignedFaces) > 0 {
+			withFaces++
+		} else {
+			withoutFaces++
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.people, prometheus.GaugeValue, named, "named")
+	ch <- prometheus.MustNewConstMetric(c.people, prometheus.GaugeValue, unnamed, "unnamed")
+	ch <- prometheus.MustNewConstMetric(c.peopleHidden, prometheus.GaugeValue, float64(hidden))
+	ch <- prometheus.MustNewConstMetric(c.assetsWithFaces, prometheus.GaugeValue, withFaces)
+	ch <- prometheus.MustNewConstMetric(c.assetsWithoutFaces, prometheus.GaugeValue, withoutFaces)
+	return nil
+}
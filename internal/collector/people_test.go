@@ -0,0 +1,53 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func TestPeopleCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/people":
+			if r.URL.Query().Get("page") == "1" {
+				w.Write([]byte(`{"people":[{"id":"p1","name":"Alice"},{"id":"p2","name":""}],"total":3,"hidden":1,"hasNextPage":true}`))
+			} else {
+				w.Write([]byte(`{"people":[{"id":"p3","name":"","isHidden":true}],"total":3,"hidden":1,"hasNextPage":false}`))
+			}
+		case "/api/search/metadata":
+			w.Write([]byte(`{"assets":{"items":[
+				{"id":"a1","people":[{"id":"p1"}]},
+				{"id":"a2","people":[],"unassignedFaces":[{"id":"f1"}]},
+				{"id":"a3","people":[],"unassignedFaces":[]}
+			],"nextPage":null}}`))
+		}
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("people"))
+
+	expected := `
+		# HELP immich_people Number of recognized people by naming (named or unnamed)
+		# TYPE immich_people gauge
+		immich_people{naming="named"} 1
+		immich_people{naming="unnamed"} 2
+		# HELP immich_people_hidden Number of hidden people
+		# TYPE immich_people_hidden gauge
+		immich_people_hidden 1
+		# HELP immich_assets_with_faces Number of assets with at least one detected face
+		# TYPE immich_assets_with_faces gauge
+		immich_assets_with_faces 2
+		# HELP immich_assets_without_faces Number of assets without detected faces
+		# TYPE immich_assets_without_faces gauge
+		immich_assets_without_faces 1
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"immich_people", "immich_people_hidden", "immich_assets_with_faces", "immich_assets_without_faces"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
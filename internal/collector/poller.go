@@ -0,0 +1,127 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Poller refreshes an ImmichCollector in the background and serves the
+// cached snapshot on scrapes, so scrape frequency does not drive Immich load
+type Poller struct {
+	collector    *ImmichCollector
+	interval     time.Duration
+	maxStaleness time.Duration
+
+	mu          sync.RWMutex
+	snapshot    []prometheus.Metric
+	refreshedAt time.Time
+	lastSuccess time.Time
+
+	lastSuccessDesc *prometheus.Desc
+	staleDesc       *prometheus.Desc
+}
+
+// NewPoller creates a Poller refreshing every interval. Snapshots older than
+// maxStaleness are not served; zero disables the check.
+func NewPoller(collector *ImmichCollector, interval, maxStaleness time.Duration) *Poller {
+	return &Poller{
+		collector:    collector,
+		interval:     interval,
+		maxStaleness: maxStaleness,
+
+		lastSuccessDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "last_successful_refresh_timestamp_seconds"),
+			"Unix time of the last background refresh in which all collectors succeeded",
+			nil, nil,
+		),
+		staleDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "cache_stale"),
+			"Whether the cached snapshot is too old to be served (1=yes, 0=no)",
+			nil, nil,
+		),
+	}
+}
+
+// Run refreshes immediately and then every interval until ctx is cancelled
+func (p *Poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		// A refresh may not run into the next one
+		refreshCtx, cancel := context.WithTimeout(ctx, p.interval)
+		p.Refresh(refreshCtx)
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh collects a new snapshot from Immich
+func (p *Poller) Refresh(ctx context.Context) {
+	ch := make(chan prometheus.Metric)
+	done := make(chan []prometheus.Metric)
+	go func() {
+		var metrics []prometheus.Metric
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		done <- metrics
+	}()
+
+	success := p.collector.collect(ctx, ch)
+	close(ch)
+	metrics := <-done
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.snapshot = metrics
+	p.refreshedAt = time.Now()
+	if success {
+		p.lastSuccess = p.refreshedAt
+	}
+}
+
+// WithContext returns the poller itself: scrapes are served from the cached
+// snapshot and never wait on Immich
+func (p *Poller) WithContext(ctx context.Context) prometheus.Collector {
+	return p
+}
+
+func (p *Poller) Describe(ch chan<- *prometheus.Desc) {
+	p.collector.Describe(ch)
+	ch <- p.lastSuccessDesc
+	ch <- p.staleDesc
+}
+
+func (p *Poller) Collect(ch chan<- prometheus.Metric) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.lastSuccess.IsZero() {
+		ch <- prometheus.MustNewConstMetric(p.lastSuccessDesc, prometheus.GaugeValue, float64(p.lastSuccess.UnixNano())/1e9)
+	}
+
+	// Before the first refresh there is nothing to serve yet
+	if p.refreshedAt.IsZero() {
+		return
+	}
+
+	stale := p.maxStaleness > 0 && time.Since(p.refreshedAt) > p.maxStaleness
+	ch <- prometheus.MustNewConstMetric(p.staleDesc, prometheus.GaugeValue, boolToFloat(stale))
+	if stale {
+		ch <- prometheus.MustNewConstMetric(p.collector.scrapeSuccess, prometheus.GaugeValue, 0)
+		return
+	}
+
+	for _, m := range p.snapshot {
+		ch <- m
+	}
+}
@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func newPollerTestServer(t *testing.T, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/api/jobs":
+			json.NewEncoder(w).Encode(immich.JobsResponse{})
+		case "/api/server/statistics":
+			json.NewEncoder(w).Encode(immich.StatisticsResponse{Photos: 42})
+		case "/api/server/storage":
+			json.NewEncoder(w).Encode(immich.StorageResponse{})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPoller_ServesCachedSnapshot(t *testing.T) {
+	var requests atomic.Int32
+	server := newPollerTestServer(t, &requests)
+
+	coll := New(immich.NewClient(server.URL, "test-key"), WithCollectors(map[string]bool{"server": false}))
+	poller := NewPoller(coll, time.Minute, 0)
+	poller.Refresh(context.Background())
+	fetched := requests.Load()
+
+	expected := `
+		# HELP immich_library_photos Total photos
+		# TYPE immich_library_photos gauge
+		immich_library_photos 42
+	`
+	for range 3 {
+		if err := testutil.CollectAndCompare(poller, strings.NewReader(expected), "immich_library_photos"); err != nil {
+			t.Errorf("unexpected metric value: %v", err)
+		}
+	}
+
+	if got := requests.Load(); got != fetched {
+		t.Errorf("expected scrapes to be served from cache, got %d extra requests", got-fetched)
+	}
+	if count := testutil.CollectAndCount(poller, "immich_last_successful_refresh_timestamp_seconds"); count != 1 {
+		t.Errorf("expected last successful refresh timestamp, got %d series", count)
+	}
+}
+
+func TestPoller_Stale(t *testing.T) {
+	var requests atomic.Int32
+	server := newPollerTestServer(t, &requests)
+
+	poller := NewPoller(New(immich.NewClient(server.URL, "test-key")), time.Minute, time.Minute)
+	poller.Refresh(context.Background())
+	poller.refreshedAt = time.Now().Add(-2 * time.Minute)
+
+	if count := testutil.CollectAndCount(poller, "immich_library_photos"); count != 0 {
+		t.Errorf("expected stale snapshot not to be served, got %d series", count)
+	}
+
+	expected := `
+		# HELP immich_cache_stale Whether the cached snapshot is too old to be served (1=yes, 0=no)
+		# TYPE immich_cache_stale gauge
+		immich_cache_stale 1
+		# HELP immich_scrape_success Whether scrape succeeded (1=yes, 0=no)
+		# TYPE immich_scrape_success gauge
+		immich_scrape_success 0
+	`
+	if err := testutil.CollectAndCompare(poller, strings.NewReader(expected), "immich_cache_stale", "immich_scrape_success"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
+
+func TestPoller_BeforeFirstRefresh(t *testing.T) {
+	poller := NewPoller(New(immich.NewClient("http://localhost", "test-key")), time.Minute, 0)
+
+	if count := testutil.CollectAndCount(poller); count != 0 {
+		t.Errorf("expected no metrics before the first refresh, got %d", count)
+	}
+}
@@ -0,0 +1,61 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+// scrapeCache shares Immich responses between the collectors of a scrape, so
+// collectors built on the same endpoint make a single request
+type scrapeCache struct {
+	statistics sharedResponse[immich.StatisticsResponse]
+	storage    sharedResponse[immich.StorageResponse]
+}
+
+// sharedResponse is a response fetched by the first collector asking for it
+type sharedResponse[T any] struct {
+	once      sync.Once
+	value     *T
+	fetchedAt time.Time
+	err       error
+}
+
+func (r *sharedResponse[T]) get(fetch func() (*T, error)) (*T, time.Time, error) {
+	r.once.Do(func() {
+		r.fetchedAt = time.Now()
+		r.value, r.err = fetch()
+	})
+	return r.value, r.fetchedAt, r.err
+}
+
+type scrapeCacheKey struct{}
+
+func withScrapeCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scrapeCacheKey{}, &scrapeCache{})
+}
+
+func scrapeCacheFrom(ctx context.Context) *scrapeCache {
+	if cache, ok := ctx.Value(scrapeCacheKey{}).(*scrapeCache); ok {
+		return cache
+	}
+	return &scrapeCache{}
+}
+
+// getStatistics returns the server statistics of the scrape in ctx and when
+// they were requested. The response is shared and must not be modified.
+func getStatistics(ctx context.Context, client *immich.Client) (*immich.StatisticsResponse, time.Time, error) {
+	return scrapeCacheFrom(ctx).statistics.get(func() (*immich.StatisticsResponse, error) {
+		return client.GetStatistics(ctx)
+	})
+}
+
+// getStorage returns the disk usage of the scrape in ctx and when it was
+// requested. The response is shared and must not be modified.
+func getStorage(ctx context.Context, client *immich.Client) (*immich.StorageResponse, time.Time, error) {
+	return scrapeCacheFrom(ctx).storage.get(func() (*immich.StorageResponse, error) {
+		return client.GetStorage(ctx)
+	})
+}
@@ -0,0 +1,88 @@
+package collector
+
+import (
+	"context"
+	"log"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func init() {
+	registerCollector("server", true, newServerCollector)
+}
+
+// serverCollector exports the Immich server version and whether a newer
+// release is available
+type serverCollector struct {
+	serverInfo             *prometheus.Desc
+	versionCheckAvailable  *prometheus.Desc
+	versionCheckLastUpdate *prometheus.Desc
+}
+
+func newServerCollector(opts options) Collector {
+	return &serverCollector{
+		serverInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "server", "info"),
+			"Immich server version and bundled dependency versions",
+			[]string{"version", "build", "nodejs", "ffmpeg", "imagemagick", "libvips", "exiftool"}, nil,
+		),
+		versionCheckAvailable: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "server", "version_check_available"),
+			"Whether a newer Immich release is available (1=yes, 0=no)",
+			[]string{"version", "release_version"}, nil,
+		),
+		versionCheckLastUpdate: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "server", "version_check_timestamp_seconds"),
+			"Unix time of Immich's last check for new releases",
+			nil, nil,
+		),
+	}
+}
+
+func (c *serverCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.serverInfo
+	ch <- c.versionCheckAvailable
+	ch <- c.versionCheckLastUpdate
+}
+
+func (c *serverCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	about, err := client.GetServerAbout(ctx)
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(c.serverInfo, prometheus.GaugeValue, 1,
+		about.Version, about.Build, about.Nodejs, about.Ffmpeg, about.Imagemagick, about.Libvips, about.Exiftool)
+
+	// The version check is optional, so its problems don't fail the collector
+	if err := c.updateVersionCheck(ctx, client, about.Version, ch); err != nil {
+		log.Printf("Skipping Immich version check: %v", err)
+	}
+	return nil
+}
+
+func (c *serverCollector) updateVersionCheck(ctx context.Context, client *immich.Client, current string, ch chan<- prometheus.Metric) error {
+	check, err := client.GetVersionCheck(ctx)
+	if err != nil {
+		return err
+	}
+	// Immich reports no release when version checks are disabled
+	if check.ReleaseVersion == "" {
+		return nil
+	}
+	version, err := immich.ParseServerVersion(current)
+	if err != nil {
+		return err
+	}
+	release, err := immich.ParseServerVersion(check.ReleaseVersion)
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(c.versionCheckAvailable, prometheus.GaugeValue,
+		boolToFloat(version.Less(release)), version.String(), release.String())
+
+	if checkedAt, err := parseTimestamp(check.CheckedAt); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.versionCheckLastUpdate, prometheus.GaugeValue, checkedAt)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func newServerTestServer(t *testing.T, releaseVersion string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/server/about":
+			w.Write([]byte(`{"version":"v1.135.3","build":"15905678","nodejs":"v22.14.0","ffmpeg":"7.0.2-7","imagemagick":"7.1.1-47","libvips":"8.16.1","exiftool":"13.00"}`))
+		case "/api/server/version-check":
+			if releaseVersion == "error" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(`{"checkedAt":"2025-07-01T12:00:00.000Z","releaseVersion":"` + releaseVersion + `"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newServerOnlyCollector(url string) *ImmichCollector {
+	return New(immich.NewClient(url, "test-key"), onlyCollectors("server"))
+}
+
+func TestServerCollector(t *testing.T) {
+	server := newServerTestServer(t, "v1.136.0")
+	collector := newServerOnlyCollector(server.URL)
+
+	expected := `
+		# HELP immich_server_info Immich server version and bundled dependency versions
+		# TYPE immich_server_info gauge
+		immich_server_info{build="15905678",exiftool="13.00",ffmpeg="7.0.2-7",imagemagick="7.1.1-47",libvips="8.16.1",nodejs="v22.14.0",version="v1.135.3"} 1
+		# HELP immich_server_version_check_available Whether a newer Immich release is available (1=yes, 0=no)
+		# TYPE immich_server_version_check_available gauge
+		immich_server_version_check_available{release_version="v1.136.0",version="v1.135.3"} 1
+		# HELP immich_server_version_check_timestamp_seconds Unix time of Immich's last check for new releases
+		# TYPE immich_server_version_check_timestamp_seconds gauge
+		immich_server_version_check_timestamp_seconds 1.7513712e+09
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"immich_server_info", "immich_server_version_check_available", "immich_server_version_check_timestamp_seconds"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
+
+func TestServerCollector_UpToDate(t *testing.T) {
+	server := newServerTestServer(t, "v1.135.3")
+	collector := newServerOnlyCollector(server.URL)
+
+	expected := `
+		# HELP immich_server_version_check_available Whether a newer Immich release is available (1=yes, 0=no)
+		# TYPE immich_server_version_check_available gauge
+		immich_server_version_check_available{release_version="v1.135.3",version="v1.135.3"} 0
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "immich_server_version_check_available"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
+
+func TestServerCollector_VersionCheckDisabled(t *testing.T) {
+	server := newServerTestServer(t, "")
+	collector := newServerOnlyCollector(server.URL)
+
+	if count := testutil.CollectAndCount(collector, "immich_server_version_check_available"); count != 0 {
+		t.Errorf("expected no version check metric, got %d series", count)
+	}
+	if count := testutil.CollectAndCount(collector, "immich_server_info"); count != 1 {
+		t.Errorf("expected server info, got %d series", count)
+	}
+}
+
+func TestServerCollector_VersionCheckFailure(t *testing.T) {
+	for _, releaseVersion := range []string{"error", "nightly"} {
+		server := newServerTestServer(t, releaseVersion)
+		collector := newServerOnlyCollector(server.URL)
+
+		expected := `
+			# HELP immich_scrape_collector_success Whether each collector succeeded (1=yes, 0=no)
+			# TYPE immich_scrape_collector_success gauge
+			immich_scrape_collector_success{collector="server"} 1
+			# HELP immich_server_info Immich server version and bundled dependency versions
+			# TYPE immich_server_info gauge
+			immich_server_info{build="15905678",exiftool="13.00",ffmpeg="7.0.2-7",imagemagick="7.1.1-47",libvips="8.16.1",nodejs="v22.14.0",version="v1.135.3"} 1
+		`
+		if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+			"immich_scrape_collector_success", "immich_server_info", "immich_server_version_check_available"); err != nil {
+			t.Errorf("release %q: unexpected metric value: %v", releaseVersion, err)
+		}
+	}
+}
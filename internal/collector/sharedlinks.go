@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+// Default window for counting shared links as expiring soon
+const defaultSharedLinkExpiryWindow = 7 * 24 * time.Hour
+
+func init() {
+	registerCollector("shared_links", false, newSharedLinksCollector)
+}
+
+// sharedLinksCollector exports an inventory of shared links, which give access
+// to assets without logging in
+type sharedLinksCollector struct {
+	now          func() time.Time
+	expiryWindow time.Duration
+
+	links               *prometheus.Desc
+	passwordProtected   *prometheus.Desc
+	allowDownload       *prometheus.Desc
+	expiring            *prometheus.Desc
+	neverExpiring       *prometheus.Desc
+	publicNeverExpiring *prometheus.Desc
+}
+
+func newSharedLinksCollector(opts options) Collector {
+	labels := []string{"type"}
+	return &sharedLinksCollector{
+		now:          time.Now,
+		expiryWindow: opts.sharedLinkExpiryWindow,
+
+		links: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "shared_links"),
+			"Number of shared links by type (album or individual)",
+			labels, nil,
+		),
+		passwordProtected: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "shared_links", "password_protected"),
+			"Number of shared links requiring a password",
+			labels, nil,
+		),
+		allowDownload: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "shared_links", "allow_download"),
+			"Number of shared links allowing downloads",
+			labels, nil,
+		),
+		expiring: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "shared_links", "expiring"),
+			"Number of shared links expiring within the configured window",
+			labels, nil,
+		),
+		neverExpiring: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "shared_links", "never_expiring"),
+			"Number of shared links without an expiry date",
+			labels, nil,
+		),
+		publicNeverExpiring: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "shared_links", "public_never_expiring"),
+			"Number of shared links with neither a password nor an expiry date",
+			labels, nil,
+		),
+	}
+}
+
+func (c *sharedLinksCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.links
+	ch <- c.passwordProtected
+	ch <- c.allowDownload
+	ch <- c.expiring
+	ch <- c.neverExpiring
+	ch <- c.publicNeverExpiring
+}
+
+type sharedLinkCounts struct {
+	links, passwordProtected, allowDownload, expiring, neverExpiring, publicNeverExpiring float64
+}
+
+func (c *sharedLinksCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	links, err := client.GetSharedLinks(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := c.now()
+	// Both types are always exported so alerts see zero rather than no data
+	counts := map[string]*sharedLinkCounts{"album": {}, "individual": {}}
+	for _, link := range links {
+		linkType := strings.ToLower(link.Type)
+		if counts[linkType] == nil {
+			counts[linkType] = &sharedLinkCounts{}
+		}
+		count := counts[linkType]
+
+		count.links++
+		protected := link.Password != nil && *link.Password != ""
+		if protected {
+			count.passwordProtected++
+		}
+		if link.AllowDownload {
+			count.allowDownload++
+		}
+
+		if link.ExpiresAt == nil {
+			count.neverExpiring++
+			if !protected {
+				count.publicNeverExpiring++
+			}
+			continue
+		}
+		expiresAt, err := time.Parse(time.RFC3339, *link.ExpiresAt)
+		if err != nil {
+			continue
+		}
+		if expiresAt.After(now) && expiresAt.Sub(now) <= c.expiryWindow {
+			count.expiring++
+		}
+	}
+
+	for linkType, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.links, prometheus.GaugeValue, count.links, linkType)
+		ch <- prometheus.MustNewConstMetric(c.passwordProtected, prometheus.GaugeValue, count.passwordProtected, linkType)
+		ch <- prometheus.MustNewConstMetric(c.allowDownload, prometheus.GaugeValue, count.allowDownload, linkType)
+		ch <- prometheus.MustNewConstMetric(c.expiring, prometheus.GaugeValue, count.expiring, linkType)
+		ch <- prometheus.MustNewConstMetric(c.neverExpiring, prometheus.GaugeValue, count.neverExpiring, linkType)
+		ch <- prometheus.MustNewConstMetric(c.publicNeverExpiring, prometheus.GaugeValue, count.publicNeverExpiring, linkType)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func TestSharedLinksCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/shared-links" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`[
+			{"id":"s1","type":"ALBUM","password":null,"allowDownload":true,"expiresAt":null},
+			{"id":"s2","type":"ALBUM","password":"secret","allowDownload":false,"expiresAt":null},
+			{"id":"s3","type":"ALBUM","password":null,"allowDownload":true,"expiresAt":"2025-07-03T12:00:00.000Z"},
+			{"id":"s4","type":"INDIVIDUAL","password":"","allowDownload":false,"expiresAt":"2025-09-01T00:00:00.000Z"},
+			{"id":"s5","type":"INDIVIDUAL","password":null,"allowDownload":false,"expiresAt":"2025-06-01T00:00:00.000Z"}
+		]`))
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("shared_links"),
+		WithSharedLinkExpiryWindow(7*24*time.Hour))
+	collector.collectors["shared_links"].(*sharedLinksCollector).now = func() time.Time {
+		return time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	}
+
+	expected := `
+		# HELP immich_shared_links_allow_download Number of shared links allowing downloads
+		# TYPE immich_shared_links_allow_download gauge
+		immich_shared_links_allow_download{type="album"} 2
+		immich_shared_links_allow_download{type="individual"} 0
+		# HELP immich_shared_links_expiring Number of shared links expiring within the configured window
+		# TYPE immich_shared_links_expiring gauge
+		immich_shared_links_expiring{type="album"} 1
+		immich_shared_links_expiring{type="individual"} 0
+		# HELP immich_shared_links_never_expiring Number of shared links without an expiry date
+		# TYPE immich_shared_links_never_expiring gauge
+		immich_shared_links_never_expiring{type="album"} 2
+		immich_shared_links_never_expiring{type="individual"} 0
+		# HELP immich_shared_links_password_protected Number of shared links requiring a password
+		# TYPE immich_shared_links_password_protected gauge
+		immich_shared_links_password_protected{type="album"} 1
+		immich_shared_links_password_protected{type="individual"} 0
+		# HELP immich_shared_links_public_never_expiring Number of shared links with neither a password nor an expiry date
+		# TYPE immich_shared_links_public_never_expiring gauge
+		immich_shared_links_public_never_expiring{type="album"} 1
+		immich_shared_links_public_never_expiring{type="individual"} 0
+		# HELP immich_shared_links Number of shared links by type (album or individual)
+		# TYPE immich_shared_links gauge
+		immich_shared_links{type="album"} 3
+		immich_shared_links{type="individual"} 2
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"immich_shared_links_allow_download", "immich_shared_links_expiring", "immich_shared_links_never_expiring",
+		"immich_shared_links_password_protected", "immich_shared_links_public_never_expiring", "immich_shared_links"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func init() {
+	registerCollector("statistics", true, newStatisticsCollector)
+}
+
+// statisticsCollector exports library totals and per-user usage from
+// /api/server/statistics, which requires an admin API key
+type statisticsCollector struct {
+	userLabel UserLabelMode
+
+	libraryPhotos *prometheus.Desc
+	libraryVideos *prometheus.Desc
+	libraryBytes  *prometheus.Desc
+	userPhotos    *prometheus.Desc
+	userVideos    *prometheus.Desc
+	userBytes     *prometheus.Desc
+}
+
+func newStatisticsCollector(opts options) Collector {
+	return &statisticsCollector{
+		userLabel: opts.userLabel,
+
+		libraryPhotos: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "library", "photos"),
+			"Total photos",
+			nil, nil,
+		),
+		libraryVideos: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "library", "videos"),
+			"Total videos",
+			nil, nil,
+		),
+		libraryBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "library", "bytes"),
+			"Total storage usage in bytes",
+			nil, nil,
+		),
+		userPhotos: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "user", "photos"),
+			"Photos per user",
+			opts.userLabel.labelNames(), nil,
+		),
+		userVideos: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "user", "videos"),
+			"Videos per user",
+			opts.userLabel.labelNames(), nil,
+		),
+		userBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "user", "bytes"),
+			"Storage per user in bytes",
+			opts.userLabel.labelNames(), nil,
+		),
+	}
+}
+
+func (c *statisticsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.libraryPhotos
+	ch <- c.libraryVideos
+	ch <- c.libraryBytes
+	ch <- c.userPhotos
+	ch <- c.userVideos
+	ch <- c.userBytes
+}
+
+func (c *statisticsCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	stats, _, err := getStatistics(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.libraryPhotos, prometheus.GaugeValue, float64(stats.Photos))
+	ch <- prometheus.MustNewConstMetric(c.libraryVideos, prometheus.GaugeValue, float64(stats.Videos))
+	ch <- prometheus.MustNewConstMetric(c.libraryBytes, prometheus.GaugeValue, float64(stats.Usage))
+
+	for _, user := range stats.UsageByUser {
+		labels := c.userLabel.labelValues(user.UserID, user.UserName)
+		ch <- prometheus.MustNewConstMetric(c.userPhotos, prometheus.GaugeValue, float64(user.Photos), labels...)
+		ch <- prometheus.MustNewConstMetric(c.userVideos, prometheus.GaugeValue, float64(user.Videos), labels...)
+		ch <- prometheus.MustNewConstMetric(c.userBytes, prometheus.GaugeValue, float64(user.Usage), labels...)
+	}
+	return nil
+}
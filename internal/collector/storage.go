@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func init() {
+	registerCollector("storage", true, newStorageCollector)
+}
+
+// storageCollector exports disk usage from /api/server/storage
+type storageCollector struct {
+	storageTotal        *prometheus.Desc
+	storageUsed         *prometheus.Desc
+	storageAvailable    *prometheus.Desc
+	storageUsagePercent *prometheus.Desc
+}
+
+func newStorageCollector(opts options) Collector {
+	return &storageCollector{
+		storageTotal: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "storage", "total_bytes"),
+			"Total disk size",
+			nil, nil,
+		),
+		storageUsed: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "storage", "used_bytes"),
+			"Disk used",
+			nil, nil,
+		),
+		storageAvailable: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "storage", "available_bytes"),
+			"Disk available",
+			nil, nil,
+		),
+		storageUsagePercent: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "storage", "usage_percent"),
+			"Disk usage percentage",
+			nil, nil,
+		),
+	}
+}
+
+func (c *storageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.storageTotal
+	ch <- c.storageUsed
+	ch <- c.storageAvailable
+	ch <- c.storageUsagePercent
+}
+
+func (c *storageCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	storage, _, err := getStorage(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.storageTotal, prometheus.GaugeValue, float64(storage.DiskSize))
+	ch <- prometheus.MustNewConstMetric(c.storageUsed, prometheus.GaugeValue, float64(storage.DiskUse))
+	ch <- prometheus.MustNewConstMetric(c.storageAvailable, prometheus.GaugeValue, float64(storage.DiskAvailable))
+	ch <- prometheus.MustNewConstMetric(c.storageUsagePercent, prometheus.GaugeValue, storage.DiskUsagePercentage)
+	return nil
+}
@@ -0,0 +1,109 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func init() {
+	registerCollector("system_config", false, newSystemConfigCollector)
+}
+
+// systemConfigCollector exports enabled server features and a hash of the
+// admin configuration, so configuration changes show up as a changing value.
+// Reading the configuration requires an admin API key.
+type systemConfigCollector struct {
+	now func() time.Time
+
+	// The last hash seen and when it changed, kept across updates
+	mu         sync.Mutex
+	lastHash   uint32
+	lastChange time.Time
+
+	featureEnabled *prometheus.Desc
+	configHash     *prometheus.Desc
+	configChanged  *prometheus.Desc
+}
+
+func newSystemConfigCollector(opts options) Collector {
+	return &systemConfigCollector{
+		now: time.Now,
+
+		featureEnabled: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "server", "feature_enabled"),
+			"Whether an optional server feature is enabled (1=yes, 0=no)",
+			[]string{"feature"}, nil,
+		),
+		configHash: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "system_config", "hash"),
+			"Hash of the admin configuration, changing whenever the configuration does",
+			nil, nil,
+		),
+		configChanged: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "system_config", "last_change_timestamp_seconds"),
+			"Unix time the admin configuration was last seen changing, or first seen",
+			nil, nil,
+		),
+	}
+}
+
+func (c *systemConfigCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.featureEnabled
+	ch <- c.configHash
+	ch <- c.configChanged
+}
+
+func (c *systemConfigCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	features, err := client.GetServerFeatures(ctx)
+	if err != nil {
+		return err
+	}
+	config, err := client.GetSystemConfig(ctx)
+	if err != nil {
+		return err
+	}
+	hash, err := hashConfig(config)
+	if err != nil {
+		return fmt.Errorf("hashing system config: %w", err)
+	}
+
+	c.mu.Lock()
+	if c.lastChange.IsZero() || hash != c.lastHash {
+		c.lastHash = hash
+		c.lastChange = c.now()
+	}
+	lastChange := c.lastChange
+	c.mu.Unlock()
+
+	for feature, enabled := range features {
+		ch <- prometheus.MustNewConstMetric(c.featureEnabled, prometheus.GaugeValue, boolToFloat(enabled), feature)
+	}
+	ch <- prometheus.MustNewConstMetric(c.configHash, prometheus.GaugeValue, float64(hash))
+	ch <- prometheus.MustNewConstMetric(c.configChanged, prometheus.GaugeValue, float64(lastChange.UnixNano())/1e9)
+	return nil
+}
+
+// hashConfig hashes the configuration independently of key order and
+// whitespace. The 32-bit FNV-1a hash is exactly representable as a float64.
+func hashConfig(config json.RawMessage) (uint32, error) {
+	var value any
+	if err := json.Unmarshal(config, &value); err != nil {
+		return 0, err
+	}
+	// Marshal sorts object keys, giving a canonical encoding
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return 0, err
+	}
+
+	h := fnv.New32a()
+	h.Write(canonical)
+	return h.Sum32(), nil
+}
@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func TestSystemConfigCollector(t *testing.T) {
+	var config atomic.Value
+	config.Store(`{"trash":{"enabled":true,"days":30},"oauth":{"enabled":false}}`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/server/features":
+			w.Write([]byte(`{"smartSearch":true,"oauth":false}`))
+		case "/api/system-config":
+			w.Write([]byte(config.Load().(string)))
+		}
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("system_config"))
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	collector.collectors["system_config"].(*systemConfigCollector).now = func() time.Time { return now }
+
+	expected := `
+		# HELP immich_server_feature_enabled Whether an optional server feature is enabled (1=yes, 0=no)
+		# TYPE immich_server_feature_enabled gauge
+		immich_server_feature_enabled{feature="oauth"} 0
+		immich_server_feature_enabled{feature="smartSearch"} 1
+		# HELP immich_system_config_last_change_timestamp_seconds Unix time the admin configuration was last seen changing, or first seen
+		# TYPE immich_system_config_last_change_timestamp_seconds gauge
+		immich_system_config_last_change_timestamp_seconds 1.7513712e+09
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"immich_server_feature_enabled", "immich_system_config_last_change_timestamp_seconds"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+	initialHash := gaugeValue(t, collector, "immich_system_config_hash")
+
+	// Reordering keys is not a change
+	now = now.Add(time.Hour)
+	config.Store(`{"oauth":{"enabled":false},"trash":{"days":30,"enabled":true}}`)
+	if hash := gaugeValue(t, collector, "immich_system_config_hash"); hash != initialHash {
+		t.Errorf("expected unchanged hash %v, got %v", initialHash, hash)
+	}
+	if changed := gaugeValue(t, collector, "immich_system_config_last_change_timestamp_seconds"); changed != 1.7513712e+09 {
+		t.Errorf("expected unchanged timestamp, got %v", changed)
+	}
+
+	config.Store(`{"oauth":{"enabled":true},"trash":{"days":30,"enabled":true}}`)
+	if hash := gaugeValue(t, collector, "immich_system_config_hash"); hash == initialHash {
+		t.Error("expected hash to change with the config")
+	}
+	if changed := gaugeValue(t, collector, "immich_system_config_last_change_timestamp_seconds"); changed != 1.7513748e+09 {
+		t.Errorf("expected timestamp of the change, got %v", changed)
+	}
+}
+
+// gaugeValue collects c and returns the value of the unlabeled gauge name
+func gaugeValue(t *testing.T, c prometheus.Collector, name string) float64 {
+	t.Helper()
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("gathering metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	t.Fatalf("metric %s not found", name)
+	return 0
+}
+
+func TestHashConfig(t *testing.T) {
+	a, err := hashConfig(json.RawMessage(`{"a":1,"b":[1,2]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := hashConfig(json.RawMessage(` { "b" : [1,2], "a" : 1 } `))
+	if a != b {
+		t.Errorf("expected equal hashes for equivalent configs, got %d and %d", a, b)
+	}
+	if _, err := hashConfig(json.RawMessage(`{`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
@@ -0,0 +1,86 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+// Selects every trashed asset when searching by trash date
+const trashedSinceEpoch = "1970-01-01T00:00:00.000Z"
+
+func init() {
+	registerCollector("trash", false, newTrashCollector)
+}
+
+// trashCollector exports trashed assets waiting for permanent deletion, which
+// still take up storage until the trash is emptied. Immich only searches the
+// assets of the API key's user, so the series describe that user alone.
+type trashCollector struct {
+	userLabel UserLabelMode
+
+	trashAssets   *prometheus.Desc
+	trashBytes    *prometheus.Desc
+	retentionDays *prometheus.Desc
+}
+
+func newTrashCollector(opts options) Collector {
+	return &trashCollector{
+		userLabel: opts.userLabel,
+
+		trashAssets: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "trash", "assets"),
+			"Number of trashed assets of the API key's user",
+			opts.userLabel.labelNames(), nil,
+		),
+		trashBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "trash", "bytes"),
+			"Size of trashed assets of the API key's user in bytes",
+			opts.userLabel.labelNames(), nil,
+		),
+		retentionDays: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "trash", "retention_days"),
+			"Days before trashed assets are deleted permanently",
+			nil, nil,
+		),
+	}
+}
+
+func (c *trashCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.trashAssets
+	ch <- c.trashBytes
+	ch <- c.retentionDays
+}
+
+func (c *trashCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	config, err := client.GetServerConfig(ctx)
+	if err != nil {
+		return err
+	}
+	me, err := client.GetMyUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	var assets, bytes float64
+	req := immich.MetadataSearchRequest{WithExif: true, WithDeleted: true, TrashedAfter: trashedSinceEpoch}
+	err = client.ForEachAsset(ctx, req, func(asset immich.Asset) {
+		if !asset.IsTrashed {
+			return
+		}
+		assets++
+		if asset.ExifInfo != nil {
+			bytes += float64(asset.ExifInfo.FileSizeInByte)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	labels := c.userLabel.labelValues(me.ID, me.Name)
+	ch <- prometheus.MustNewConstMetric(c.retentionDays, prometheus.GaugeValue, float64(config.TrashDays))
+	ch <- prometheus.MustNewConstMetric(c.trashAssets, prometheus.GaugeValue, assets, labels...)
+	ch <- prometheus.MustNewConstMetric(c.trashBytes, prometheus.GaugeValue, bytes, labels...)
+	return nil
+}
@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func TestTrashCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/server/config":
+			w.Write([]byte(`{"trashDays":30}`))
+		case "/api/users/me":
+			w.Write([]byte(`{"id":"u1","name":"alice"}`))
+		case "/api/search/metadata":
+			var req immich.MetadataSearchRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if !req.WithDeleted || req.TrashedAfter == "" {
+				t.Errorf("expected trashed assets to be requested, got %+v", req)
+			}
+			// The search only returns the API key user's own assets
+			w.Write([]byte(`{"assets":{"items":[
+				{"id":"a1","ownerId":"u1","isTrashed":true,"exifInfo":{"fileSizeInByte":1000}},
+				{"id":"a2","ownerId":"u1","isTrashed":true,"exifInfo":{"fileSizeInByte":500}},
+				{"id":"a3","ownerId":"u1","isTrashed":true,"exifInfo":null},
+				{"id":"a4","ownerId":"u1","isTrashed":false,"exifInfo":{"fileSizeInByte":9999}}
+			],"nextPage":null}}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("trash"))
+
+	expected := `
+		# HELP immich_trash_assets Number of trashed assets of the API key's user
+		# TYPE immich_trash_assets gauge
+		immich_trash_assets{user="alice"} 3
+		# HELP immich_trash_bytes Size of trashed assets of the API key's user in bytes
+		# TYPE immich_trash_bytes gauge
+		immich_trash_bytes{user="alice"} 1500
+		# HELP immich_trash_retention_days Days before trashed assets are deleted permanently
+		# TYPE immich_trash_retention_days gauge
+		immich_trash_retention_days 30
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"immich_trash_assets", "immich_trash_bytes", "immich_trash_retention_days"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
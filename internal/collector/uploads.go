@@ -0,0 +1,131 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+	"github.com/victorarias/immich-prometheus-exporter/internal/state"
+)
+
+func init() {
+	registerCollector("uploads", false, newUploadsCollector)
+}
+
+// uploadsState is the last statistics snapshot and the counters derived from
+// it, kept in the state store across restarts
+type uploadsState struct {
+	Users map[string]userUploads `json:"users"`
+}
+
+type userUploads struct {
+	Photos int64 `json:"photos"`
+	Videos int64 `json:"videos"`
+	Usage  int64 `json:"usage"`
+
+	PhotosUploaded float64 `json:"photos_uploaded"`
+	VideosUploaded float64 `json:"videos_uploaded"`
+	BytesAdded     float64 `json:"bytes_added"`
+}
+
+// uploadsCollector turns the per-user statistics gauges into counters, so
+// rate() shows upload activity. Only increases are counted: deletions lower
+// the baseline without decreasing the counters, so uploads and deletions
+// between two updates offset each other.
+type uploadsCollector struct {
+	userLabel UserLabelMode
+	store     *state.Store
+
+	// Updates are serialized since they share the snapshot
+	mu        sync.Mutex
+	state     *uploadsState
+	loaded    bool
+	fetchedAt time.Time
+
+	assetsUploaded *prometheus.Desc
+	bytesAdded     *prometheus.Desc
+}
+
+func newUploadsCollector(opts options) Collector {
+	return &uploadsCollector{
+		userLabel: opts.userLabel,
+		store:     opts.state,
+
+		assetsUploaded: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "assets", "uploaded_total"),
+			"Number of assets uploaded per user and type (photo or video), derived from statistics",
+			append(opts.userLabel.labelNames(), "type"), nil,
+		),
+		bytesAdded: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "bytes_added_total"),
+			"Bytes added to the library per user, derived from statistics",
+			opts.userLabel.labelNames(), nil,
+		),
+	}
+}
+
+func (c *uploadsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.assetsUploaded
+	ch <- c.bytesAdded
+}
+
+func (c *uploadsCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	stats, fetchedAt, err := getStatistics(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.loaded {
+		c.state = &uploadsState{}
+		if _, err := c.store.Get("uploads", c.state); err != nil {
+			return err
+		}
+		c.loaded = true
+	}
+
+	// Concurrent scrapes can finish out of order, and a snapshot older than
+	// the last one applied would count the difference between them again
+	if !fetchedAt.Before(c.fetchedAt) {
+		c.apply(stats)
+		c.fetchedAt = fetchedAt
+		if err := c.store.Set("uploads", c.state); err != nil {
+			return fmt.Errorf("saving upload counters: %w", err)
+		}
+	}
+
+	for _, usage := range stats.UsageByUser {
+		user, ok := c.state.Users[usage.UserID]
+		if !ok {
+			continue
+		}
+		labels := c.userLabel.labelValues(usage.UserID, usage.UserName)
+		ch <- prometheus.MustNewConstMetric(c.assetsUploaded, prometheus.CounterValue, user.PhotosUploaded, append(labels, "photo")...)
+		ch <- prometheus.MustNewConstMetric(c.assetsUploaded, prometheus.CounterValue, user.VideosUploaded, append(labels, "video")...)
+		ch <- prometheus.MustNewConstMetric(c.bytesAdded, prometheus.CounterValue, user.BytesAdded, labels...)
+	}
+	return nil
+}
+
+// apply counts the increases since the previous snapshot and makes stats the
+// new baseline
+func (c *uploadsCollector) apply(stats *immich.StatisticsResponse) {
+	// Users missing from the statistics were deleted and are dropped
+	users := make(map[string]userUploads, len(stats.UsageByUser))
+	for _, usage := range stats.UsageByUser {
+		user, seen := c.state.Users[usage.UserID]
+		if seen {
+			user.PhotosUploaded += float64(max(usage.Photos-user.Photos, 0))
+			user.VideosUploaded += float64(max(usage.Videos-user.Videos, 0))
+			user.BytesAdded += float64(max(usage.Usage-user.Usage, 0))
+		}
+		user.Photos, user.Videos, user.Usage = usage.Photos, usage.Videos, usage.Usage
+		users[usage.UserID] = user
+	}
+	c.state.Users = users
+}
@@ -0,0 +1,107 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+	"github.com/victorarias/immich-prometheus-exporter/internal/state"
+)
+
+func TestUploadsCollector(t *testing.T) {
+	var stats atomic.Value
+	stats.Store(`{"usageByUser":[{"userId":"u1","userName":"alice","photos":10,"videos":2,"usage":1000}]}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(stats.Load().(string)))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	store, err := state.Open(path)
+	if err != nil {
+		t.Fatalf("opening state: %v", err)
+	}
+	client := immich.NewClient(server.URL, "test-key")
+	collector := New(client, onlyCollectors("uploads"), WithStateStore(store))
+
+	// The first snapshot is the baseline
+	testutil.CollectAndCount(collector)
+
+	// 5 photos uploaded, then 3 photos deleted and 1 video uploaded
+	stats.Store(`{"usageByUser":[{"userId":"u1","userName":"alice","photos":15,"videos":2,"usage":1500}]}`)
+	testutil.CollectAndCount(collector)
+	stats.Store(`{"usageByUser":[{"userId":"u1","userName":"alice","photos":12,"videos":3,"usage":1300}]}`)
+	testutil.CollectAndCount(collector)
+
+	// Counters continue from the state file after a restart
+	store, err = state.Open(path)
+	if err != nil {
+		t.Fatalf("reopening state: %v", err)
+	}
+	collector = New(client, onlyCollectors("uploads"), WithStateStore(store))
+	stats.Store(`{"usageByUser":[{"userId":"u1","userName":"alice","photos":14,"videos":3,"usage":1600}]}`)
+
+	expected := `
+		# HELP immich_assets_uploaded_total Number of assets uploaded per user and type (photo or video), derived from statistics
+		# TYPE immich_assets_uploaded_total counter
+		immich_assets_uploaded_total{type="photo",user="alice"} 7
+		immich_assets_uploaded_total{type="video",user="alice"} 1
+		# HELP immich_bytes_added_total Bytes added to the library per user, derived from statistics
+		# TYPE immich_bytes_added_total counter
+		immich_bytes_added_total{user="alice"} 800
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"immich_assets_uploaded_total", "immich_bytes_added_total"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
+
+func TestUploadsCollector_OutOfOrder(t *testing.T) {
+	var stats atomic.Value
+	var requests atomic.Int32
+	stats.Store(`{"usageByUser":[{"userId":"u1","userName":"alice","photos":10}]}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(stats.Load().(string)))
+	}))
+	defer server.Close()
+
+	client := immich.NewClient(server.URL, "test-key")
+	collector := New(client, onlyCollectors("uploads", "statistics"))
+	testutil.CollectAndCount(collector)
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected statistics to be requested once per scrape, got %d requests", n)
+	}
+
+	// A slow scrape fetches 15 photos, then a newer one applies 20 first
+	stale := withScrapeCache(context.Background())
+	stats.Store(`{"usageByUser":[{"userId":"u1","userName":"alice","photos":15}]}`)
+	if _, _, err := getStatistics(stale, client); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stats.Store(`{"usageByUser":[{"userId":"u1","userName":"alice","photos":20}]}`)
+	testutil.CollectAndCount(collector)
+
+	ch := make(chan prometheus.Metric, 10)
+	if err := collector.collectors["uploads"].Update(stale, client, ch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The stale snapshot must not become the baseline
+	expected := `
+		# HELP immich_assets_uploaded_total Number of assets uploaded per user and type (photo or video), derived from statistics
+		# TYPE immich_assets_uploaded_total counter
+		immich_assets_uploaded_total{type="photo",user="alice"} 10
+		immich_assets_uploaded_total{type="video",user="alice"} 0
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "immich_assets_uploaded_total"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// UserLabelMode selects how users are identified in per-user series
+type UserLabelMode string
+
+const (
+	// UserLabelName labels series with the user's display name
+	UserLabelName UserLabelMode = "name"
+	// UserLabelID labels series with the stable user ID
+	UserLabelID UserLabelMode = "id"
+	// UserLabelBoth labels series with the name and a separate user_id label
+	UserLabelBoth UserLabelMode = "both"
+	// UserLabelHashed labels series with a hash of the user ID, keeping real
+	// names and IDs out of Prometheus
+	UserLabelHashed UserLabelMode = "hashed"
+)
+
+// ParseUserLabelMode validates a user label mode from the command line or
+// config file
+func ParseUserLabelMode(s string) (UserLabelMode, error) {
+	switch mode := UserLabelMode(s); mode {
+	case UserLabelName, UserLabelID, UserLabelBoth, UserLabelHashed:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown user label mode %q (available: name, id, both, hashed)", s)
+	}
+}
+
+// labelNames returns the labels identifying a user in per-user series
+func (m UserLabelMode) labelNames() []string {
+	if m == UserLabelBoth {
+		return []string{"user", "user_id"}
+	}
+	return []string{"user"}
+}
+
+// labelValues returns the values for labelNames
+func (m UserLabelMode) labelValues(id, name string) []string {
+	switch m {
+	case UserLabelID:
+		return []string{id}
+	case UserLabelBoth:
+		return []string{name, id}
+	case UserLabelHashed:
+		return []string{hashUserID(id)}
+	default:
+		return []string{name}
+	}
+}
+
+// userID returns the value for user_id labels, hashed in hashed mode
+func (m UserLabelMode) userID(id string) string {
+	if m == UserLabelHashed {
+		return hashUserID(id)
+	}
+	return id
+}
+
+// userName returns a name identifying the user, such as the display name or
+// storage label, or an empty string in hashed mode
+func (m UserLabelMode) userName(name string) string {
+	if m == UserLabelHashed {
+		return ""
+	}
+	return name
+}
+
+func hashUserID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:8])
+}
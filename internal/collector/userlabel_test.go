@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func TestParseUserLabelMode(t *testing.T) {
+	for _, s := range []string{"name", "id", "both", "hashed"} {
+		if _, err := ParseUserLabelMode(s); err != nil {
+			t.Errorf("unexpected error for %s: %v", s, err)
+		}
+	}
+	if _, err := ParseUserLabelMode("email"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+func TestStatisticsCollector_UserLabel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(immich.StatisticsResponse{
+			UsageByUser: []immich.UserUsage{
+				{UserID: "u1", UserName: "alice", Photos: 10},
+			},
+		})
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		mode     UserLabelMode
+		expected string
+	}{
+		{UserLabelName, `immich_user_photos{user="alice"} 10`},
+		{UserLabelID, `immich_user_photos{user="u1"} 10`},
+		{UserLabelBoth, `immich_user_photos{user="alice",user_id="u1"} 10`},
+		{UserLabelHashed, `immich_user_photos{user="` + hashUserID("u1") + `"} 10`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("statistics"), WithUserLabel(tt.mode))
+
+			expected := `
+				# HELP immich_user_photos Photos per user
+				# TYPE immich_user_photos gauge
+				` + tt.expected + "\n"
+			if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "immich_user_photos"); err != nil {
+				t.Errorf("unexpected metric value: %v", err)
+			}
+		})
+	}
+}
+
+func TestHashUserID(t *testing.T) {
+	hash := hashUserID("u1")
+	if hash == "u1" || len(hash) != 16 {
+		t.Errorf("expected 16 character hash, got %q", hash)
+	}
+	if hashUserID("u1") != hash {
+		t.Error("expected hash to be stable")
+	}
+	if hashUserID("u2") == hash {
+		t.Error("expected different users to hash differently")
+	}
+}
@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func init() {
+	registerCollector("users", false, newUsersCollector)
+}
+
+// usersCollector exports per-user quotas and account status from the admin
+// users endpoint, keyed by the stable user ID
+type usersCollector struct {
+	userLabel UserLabelMode
+
+	userInfo       *prometheus.Desc
+	userAdmin      *prometheus.Desc
+	userDeleted    *prometheus.Desc
+	quotaBytes     *prometheus.Desc
+	quotaUsage     *prometheus.Desc
+	quotaUsageRate *prometheus.Desc
+}
+
+func newUsersCollector(opts options) Collector {
+	return &usersCollector{
+		userLabel: opts.userLabel,
+
+		userInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "user", "info"),
+			"User account details",
+			[]string{"user_id", "name", "storage_label", "status"}, nil,
+		),
+		userAdmin: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "user", "admin"),
+			"Whether the user is an admin (1=yes, 0=no)",
+			[]string{"user_id"}, nil,
+		),
+		userDeleted: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "user", "deleted"),
+			"Whether the user is deleted or pending removal (1=yes, 0=no)",
+			[]string{"user_id"}, nil,
+		),
+		quotaBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "user", "quota_bytes"),
+			"Storage quota per user in bytes, only for users with a quota",
+			[]string{"user_id"}, nil,
+		),
+		quotaUsage: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "user", "quota_usage_bytes"),
+			"Storage counted against the quota per user in bytes",
+			[]string{"user_id"}, nil,
+		),
+		quotaUsageRate: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "user", "quota_usage_ratio"),
+			"Fraction of the storage quota in use per user, only for users with a quota",
+			[]string{"user_id"}, nil,
+		),
+	}
+}
+
+func (c *usersCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.userInfo
+	ch <- c.userAdmin
+	ch <- c.userDeleted
+	ch <- c.quotaBytes
+	ch <- c.quotaUsage
+	ch <- c.quotaUsageRate
+}
+
+func (c *usersCollector) Update(ctx context.Context, client *immich.Client, ch chan<- prometheus.Metric) error {
+	users, err := client.GetAdminUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		id := c.userLabel.userID(user.ID)
+		deleted := user.DeletedAt != "" || user.Status != "active"
+		// Storage labels usually repeat the name, so hashed mode hides them too
+		name, storageLabel := c.userLabel.userName(user.Name), c.userLabel.userName(user.StorageLabel)
+
+		ch <- prometheus.MustNewConstMetric(c.userInfo, prometheus.GaugeValue, 1, id, name, storageLabel, user.Status)
+		ch <- prometheus.MustNewConstMetric(c.userAdmin, prometheus.GaugeValue, boolToFloat(user.IsAdmin), id)
+		ch <- prometheus.MustNewConstMetric(c.userDeleted, prometheus.GaugeValue, boolToFloat(deleted), id)
+		ch <- prometheus.MustNewConstMetric(c.quotaUsage, prometheus.GaugeValue, float64(user.QuotaUsageInBytes), id)
+
+		// Users without a quota (or an unlimited 0 quota) have no ratio
+		if user.QuotaSizeInBytes == nil || *user.QuotaSizeInBytes <= 0 {
+			continue
+		}
+		quota := float64(*user.QuotaSizeInBytes)
+		ch <- prometheus.MustNewConstMetric(c.quotaBytes, prometheus.GaugeValue, quota, id)
+		ch <- prometheus.MustNewConstMetric(c.quotaUsageRate, prometheus.GaugeValue, float64(user.QuotaUsageInBytes)/quota, id)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/victorarias/immich-prometheus-exporter/internal/immich"
+)
+
+func TestUsersCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/admin/users" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`[
+			{"id":"u1","name":"alice","isAdmin":true,"storageLabel":"admin","quotaSizeInBytes":1000,"quotaUsageInBytes":900,"status":"active"},
+			{"id":"u2","name":"bob","storageLabel":null,"quotaSizeInBytes":null,"quotaUsageInBytes":5,"status":"deleted","deletedAt":"2025-01-01T00:00:00.000Z"}
+		]`))
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("users"))
+
+	expected := `
+		# HELP immich_user_info User account details
+		# TYPE immich_user_info gauge
+		immich_user_info{name="alice",status="active",storage_label="admin",user_id="u1"} 1
+		immich_user_info{name="bob",status="deleted",storage_label="",user_id="u2"} 1
+		# HELP immich_user_deleted Whether the user is deleted or pending removal (1=yes, 0=no)
+		# TYPE immich_user_deleted gauge
+		immich_user_deleted{user_id="u1"} 0
+		immich_user_deleted{user_id="u2"} 1
+		# HELP immich_user_quota_bytes Storage quota per user in bytes, only for users with a quota
+		# TYPE immich_user_quota_bytes gauge
+		immich_user_quota_bytes{user_id="u1"} 1000
+		# HELP immich_user_quota_usage_ratio Fraction of the storage quota in use per user, only for users with a quota
+		# TYPE immich_user_quota_usage_ratio gauge
+		immich_user_quota_usage_ratio{user_id="u1"} 0.9
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"immich_user_info", "immich_user_deleted", "immich_user_quota_bytes", "immich_user_quota_usage_ratio"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
+
+func TestUsersCollector_Hashed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"u1","name":"alice","storageLabel":"alice","quotaUsageInBytes":1,"status":"active"}]`))
+	}))
+	defer server.Close()
+
+	collector := New(immich.NewClient(server.URL, "test-key"), onlyCollectors("users"), WithUserLabel(UserLabelHashed))
+
+	expected := `
+		# HELP immich_user_info User account details
+		# TYPE immich_user_info gauge
+		immich_user_info{name="",status="active",storage_label="",user_id="` + hashUserID("u1") + `"} 1
+	`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "immich_user_info"); err != nil {
+		t.Errorf("unexpected metric value: %v", err)
+	}
+}
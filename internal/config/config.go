@@ -0,0 +1,348 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/victorarias/immich-prometheus-exporter/internal/collector"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	defaultListenAddress = ":8080"
+	defaultTimeout       = 10 * time.Second
+
+	defaultScrapeTimeoutOffset = 500 * time.Millisecond
+	defaultAlbumSeriesLimit    = 50
+	defaultSharedLinkWindow    = 7 * 24 * time.Hour
+
+	defaultInventorySeriesLimit    = 20
+	defaultInventoryRescanInterval = 24 * time.Hour
+
+	defaultQueueStallWindow = time.Hour
+
+	defaultForecastWindow         = 7 * 24 * time.Hour
+	defaultForecastSampleInterval = time.Hour
+)
+
+// Config is the exporter configuration, loaded from an optional YAML file
+// and overridden by environment variables
+type Config struct {
+	Immich      Immich            `yaml:"immich"`
+	Web         Web               `yaml:"web"`
+	Polling     Polling           `yaml:"polling"`
+	Collectors  map[string]bool   `yaml:"collectors"`
+	Labels      Labels            `yaml:"labels"`
+	Jobs        Jobs              `yaml:"jobs"`
+	Albums      Albums            `yaml:"albums"`
+	SharedLinks SharedLinks       `yaml:"shared_links"`
+	Inventory   Inventory         `yaml:"inventory"`
+	Forecast    Forecast          `yaml:"forecast"`
+	State       State             `yaml:"state"`
+	Targets     map[string]Target `yaml:"targets"`
+}
+
+// Immich is the instance served on /metrics
+type Immich struct {
+	URL        string        `yaml:"url"`
+	APIKey     string        `yaml:"api_key"`
+	APIKeyFile string        `yaml:"api_key_file"`
+	Timeout    time.Duration `yaml:"timeout"`
+	// Optional machine learning service, probed by the machine_learning
+	// collector
+	MachineLearningURL string `yaml:"machine_learning_url"`
+}
+
+type Web struct {
+	ListenAddress string `yaml:"listen_address"`
+	// Subtracted from the scrape timeout Prometheus sends, leaving time to
+	// return partial metrics
+	ScrapeTimeoutOffset time.Duration `yaml:"scrape_timeout_offset"`
+}
+
+// Polling enables background refreshes, with scrapes served from the cached
+// snapshot. A zero interval scrapes Immich live on every request.
+type Polling struct {
+	Interval time.Duration `yaml:"interval"`
+	// Snapshots older than this are not served, defaults to three intervals
+	MaxStaleness time.Duration `yaml:"max_staleness"`
+}
+
+type Labels struct {
+	// Constant labels added to every Immich metric
+	Constant map[string]string `yaml:"constant"`
+	// How users are identified in per-user series: name, id, both or hashed
+	User string `yaml:"user"`
+}
+
+// Jobs configures the jobs collector
+type Jobs struct {
+	// Time a queue with active jobs may go without progress before it
+	// counts as stalled
+	StallWindow time.Duration `yaml:"stall_window"`
+	// Per-queue overrides of StallWindow, for slow queues such as
+	// videoConversion
+	StallWindows map[string]time.Duration `yaml:"stall_windows"`
+}
+
+// Albums configures the albums collector
+type Albums struct {
+	// Number of largest albums exported with their own asset count series
+	MaxSeries int `yaml:"max_series"`
+}
+
+// SharedLinks configures the shared_links collector
+type SharedLinks struct {
+	// Links expiring within this window are counted as expiring
+	ExpiryWindow time.Duration `yaml:"expiry_window"`
+}
+
+// Inventory configures the inventory collector
+type Inventory struct {
+	// Number of most common MIME types, file extensions and camera makes
+	// exported with their own series
+	MaxSeries int `yaml:"max_series"`
+	// How often all assets are scanned instead of only the changed ones
+	FullRescanInterval time.Duration `yaml:"full_rescan_interval"`
+}
+
+// Forecast configures the forecast collector
+type Forecast struct {
+	// Span of samples the forecast is fitted on
+	Window time.Duration `yaml:"window"`
+	// Minimum time between samples
+	SampleInterval time.Duration `yaml:"sample_interval"`
+}
+
+// State configures where derived metrics such as counters are persisted
+type State struct {
+	// JSON file kept across restarts; state is lost on restart when empty
+	File string `yaml:"file"`
+}
+
+// Target is a named Immich instance that can be scraped through /probe
+type Target struct {
+	URL                string `yaml:"url"`
+	APIKey             string `yaml:"api_key"`
+	APIKeyFile         string `yaml:"api_key_file"`
+	MachineLearningURL string `yaml:"machine_learning_url"`
+}
+
+// Load reads the configuration file at path, applies environment variable
+// overrides and validates the result. An empty path loads defaults only.
+func Load(path string) (*Config, error) {
+	cfg := &Config{
+		Immich: Immich{Timeout: defaultTimeout},
+		Web: Web{
+			ListenAddress:       defaultListenAddress,
+			ScrapeTimeoutOffset: defaultScrapeTimeoutOffset,
+		},
+		Labels:      Labels{User: string(collector.UserLabelName)},
+		Jobs:        Jobs{StallWindow: defaultQueueStallWindow},
+		Albums:      Albums{MaxSeries: defaultAlbumSeriesLimit},
+		SharedLinks: SharedLinks{ExpiryWindow: defaultSharedLinkWindow},
+		Inventory: Inventory{
+			MaxSeries:          defaultInventorySeriesLimit,
+			FullRescanInterval: defaultInventoryRescanInterval,
+		},
+		Forecast: Forecast{
+			Window:         defaultForecastWindow,
+			SampleInterval: defaultForecastSampleInterval,
+		},
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading config file: %w", err)
+		}
+
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+	}
+
+	cfg.applyEnv()
+
+	if cfg.Polling.Interval > 0 && cfg.Polling.MaxStaleness == 0 {
+		cfg.Polling.MaxStaleness = 3 * cfg.Polling.Interval
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// applyEnv lets the environment variables used by existing Docker setups
+// take precedence over file values
+func (c *Config) applyEnv() {
+	if v := os.Getenv("IMMICH_URL"); v != "" {
+		c.Immich.URL = v
+	}
+	// Either key variable replaces both file values; setting both is
+	// reported by validate
+	apiKey, apiKeyFile := os.Getenv("IMMICH_API_KEY"), os.Getenv("IMMICH_API_KEY_FILE")
+	if apiKey != "" || apiKeyFile != "" {
+		c.Immich.APIKey = apiKey
+		c.Immich.APIKeyFile = apiKeyFile
+	}
+	if v := os.Getenv("IMMICH_MACHINE_LEARNING_URL"); v != "" {
+		c.Immich.MachineLearningURL = v
+	}
+	if v := os.Getenv("LISTEN_ADDRESS"); v != "" {
+		c.Web.ListenAddress = v
+	}
+}
+
+// TargetNames returns the configured target names in sorted order
+func (c *Config) TargetNames() []string {
+	names := make([]string, 0, len(c.Targets))
+	for name := range c.Targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Config) validate() error {
+	if c.Immich.URL == "" && len(c.Targets) == 0 {
+		return errors.New("immich.url is required (or set IMMICH_URL) unless targets are configured")
+	}
+	if c.Immich.URL != "" {
+		if err := validateEndpoint("immich", c.Immich.URL, c.Immich.APIKey, c.Immich.APIKeyFile); err != nil {
+			return err
+		}
+	}
+	if err := validateOptionalURL("immich.machine_learning_url", c.Immich.MachineLearningURL); err != nil {
+		return err
+	}
+	if c.Immich.Timeout <= 0 {
+		return errors.New("immich.timeout: must be positive")
+	}
+
+	if c.Web.ListenAddress == "" {
+		return errors.New("web.listen_address: must not be empty")
+	}
+	if c.Web.ScrapeTimeoutOffset < 0 {
+		return errors.New("web.scrape_timeout_offset: must not be negative")
+	}
+
+	if c.Polling.Interval < 0 {
+		return errors.New("polling.interval: must not be negative")
+	}
+	if c.Polling.MaxStaleness < 0 {
+		return errors.New("polling.max_staleness: must not be negative")
+	}
+
+	known := collector.Names()
+	for name := range c.Collectors {
+		if !slices.Contains(known, name) {
+			return fmt.Errorf("collectors.%s: unknown collector (available: %s)", name, strings.Join(known, ", "))
+		}
+	}
+
+	for name := range c.Labels.Constant {
+		if !validLabelName(name) {
+			return fmt.Errorf("labels.constant.%s: invalid label name", name)
+		}
+	}
+
+	if _, err := collector.ParseUserLabelMode(c.Labels.User); err != nil {
+		return fmt.Errorf("labels.user: %w", err)
+	}
+
+	if c.Jobs.StallWindow <= 0 {
+		return errors.New("jobs.stall_window: must be positive")
+	}
+	for queue, window := range c.Jobs.StallWindows {
+		if window <= 0 {
+			return fmt.Errorf("jobs.stall_windows.%s: must be positive", queue)
+		}
+	}
+
+	if c.Albums.MaxSeries < 0 {
+		return errors.New("albums.max_series: must not be negative")
+	}
+	if c.SharedLinks.ExpiryWindow <= 0 {
+		return errors.New("shared_links.expiry_window: must be positive")
+	}
+	if c.Inventory.MaxSeries < 0 {
+		return errors.New("inventory.max_series: must not be negative")
+	}
+	if c.Inventory.FullRescanInterval <= 0 {
+		return errors.New("inventory.full_rescan_interval: must be positive")
+	}
+	if c.Forecast.SampleInterval <= 0 {
+		return errors.New("forecast.sample_interval: must be positive")
+	}
+	if c.Forecast.Window < 2*c.Forecast.SampleInterval {
+		return errors.New("forecast.window: must span at least two sample intervals")
+	}
+	if c.Forecast.Window > collector.MaxForecastSamples*c.Forecast.SampleInterval {
+		return fmt.Errorf("forecast.window: must span at most %d sample intervals", collector.MaxForecastSamples)
+	}
+
+	for _, name := range c.TargetNames() {
+		target := c.Targets[name]
+		if err := validateEndpoint("targets."+name, target.URL, target.APIKey, target.APIKeyFile); err != nil {
+			return err
+		}
+		if err := validateOptionalURL("targets."+name+".machine_learning_url", target.MachineLearningURL); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateEndpoint(key, rawURL, apiKey, apiKeyFile string) error {
+	if !isHTTPURL(rawURL) {
+		return fmt.Errorf("%s.url: %q is not an http(s) URL", key, rawURL)
+	}
+	if apiKey == "" && apiKeyFile == "" {
+		return fmt.Errorf("%s: one of api_key or api_key_file is required", key)
+	}
+	if apiKey != "" && apiKeyFile != "" {
+		return fmt.Errorf("%s: api_key and api_key_file are mutually exclusive", key)
+	}
+	return nil
+}
+
+func validateOptionalURL(key, rawURL string) error {
+	if rawURL != "" && !isHTTPURL(rawURL) {
+		return fmt.Errorf("%s: %q is not an http(s) URL", key, rawURL)
+	}
+	return nil
+}
+
+func isHTTPURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func validLabelName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "__") {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
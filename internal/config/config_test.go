@@ -0,0 +1,348 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+	return path
+}
+
+// clearEnv keeps variables from the test environment out of Load
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"IMMICH_URL", "IMMICH_API_KEY", "IMMICH_API_KEY_FILE", "IMMICH_MACHINE_LEARNING_URL", "LISTEN_ADDRESS"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestLoad_File(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, `
+immich:
+  url: http://immich:2283
+  api_key: file-key
+  timeout: 5s
+web:
+  listen_address: ":9100"
+collectors:
+  storage: false
+labels:
+  constant:
+    cluster: home
+  user: hashed
+jobs:
+  stall_windows:
+    videoConversion: 6h
+state:
+  file: /var/lib/immich-exporter/state.json
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Immich.URL != "http://immich:2283" {
+		t.Errorf("unexpected URL: %s", cfg.Immich.URL)
+	}
+	if cfg.Immich.Timeout != 5*time.Second {
+		t.Errorf("expected 5s timeout, got %s", cfg.Immich.Timeout)
+	}
+	if cfg.Web.ListenAddress != ":9100" {
+		t.Errorf("unexpected listen address: %s", cfg.Web.ListenAddress)
+	}
+	if enabled, ok := cfg.Collectors["storage"]; !ok || enabled {
+		t.Errorf("expected storage collector to be disabled, got %v", cfg.Collectors)
+	}
+	if cfg.Labels.Constant["cluster"] != "home" {
+		t.Errorf("unexpected constant labels: %v", cfg.Labels.Constant)
+	}
+	if cfg.Labels.User != "hashed" {
+		t.Errorf("expected hashed user labels, got %s", cfg.Labels.User)
+	}
+	if cfg.Jobs.StallWindow != time.Hour || cfg.Jobs.StallWindows["videoConversion"] != 6*time.Hour {
+		t.Errorf("unexpected stall windows: %+v", cfg.Jobs)
+	}
+	if cfg.State.File != "/var/lib/immich-exporter/state.json" {
+		t.Errorf("unexpected state file: %s", cfg.State.File)
+	}
+}
+
+func TestLoad_EnvOnly(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("IMMICH_URL", "http://immich:2283")
+	t.Setenv("IMMICH_API_KEY", "env-key")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Immich.APIKey != "env-key" {
+		t.Errorf("unexpected API key: %s", cfg.Immich.APIKey)
+	}
+	if cfg.Web.ListenAddress != ":8080" {
+		t.Errorf("expected default listen address, got %s", cfg.Web.ListenAddress)
+	}
+	if cfg.Immich.Timeout != 10*time.Second {
+		t.Errorf("expected default timeout, got %s", cfg.Immich.Timeout)
+	}
+	if cfg.Labels.User != "name" {
+		t.Errorf("expected default user label mode, got %s", cfg.Labels.User)
+	}
+	if cfg.Albums.MaxSeries != 50 {
+		t.Errorf("expected default album series limit, got %d", cfg.Albums.MaxSeries)
+	}
+	if cfg.SharedLinks.ExpiryWindow != 7*24*time.Hour {
+		t.Errorf("expected default shared link expiry window, got %s", cfg.SharedLinks.ExpiryWindow)
+	}
+	if cfg.Inventory.MaxSeries != 20 || cfg.Inventory.FullRescanInterval != 24*time.Hour {
+		t.Errorf("unexpected inventory defaults: %+v", cfg.Inventory)
+	}
+}
+
+func TestLoad_EnvOverridesFile(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("IMMICH_API_KEY", "env-key")
+	t.Setenv("LISTEN_ADDRESS", ":9999")
+	path := writeConfig(t, `
+immich:
+  url: http://immich:2283
+  api_key_file: /run/secrets/immich
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Immich.APIKey != "env-key" || cfg.Immich.APIKeyFile != "" {
+		t.Errorf("expected env API key to replace key file, got %+v", cfg.Immich)
+	}
+	if cfg.Web.ListenAddress != ":9999" {
+		t.Errorf("unexpected listen address: %s", cfg.Web.ListenAddress)
+	}
+}
+
+func TestLoad_EnvAPIKeyFile(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("IMMICH_API_KEY_FILE", "/run/secrets/env")
+	path := writeConfig(t, `
+immich:
+  url: http://immich:2283
+  api_key: file-key
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Immich.APIKeyFile != "/run/secrets/env" || cfg.Immich.APIKey != "" {
+		t.Errorf("expected env key file to replace API key, got %+v", cfg.Immich)
+	}
+}
+
+func TestLoad_EnvBothAPIKeys(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("IMMICH_URL", "http://immich:2283")
+	t.Setenv("IMMICH_API_KEY", "env-key")
+	t.Setenv("IMMICH_API_KEY_FILE", "/run/secrets/env")
+
+	_, err := Load("")
+	if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("expected mutually exclusive error, got %v", err)
+	}
+}
+
+func TestLoad_Polling(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, `
+immich:
+  url: http://immich:2283
+  api_key: key
+polling:
+  interval: 1m
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Polling.Interval != time.Minute {
+		t.Errorf("expected 1m interval, got %s", cfg.Polling.Interval)
+	}
+	if cfg.Polling.MaxStaleness != 3*time.Minute {
+		t.Errorf("expected max staleness to default to 3 intervals, got %s", cfg.Polling.MaxStaleness)
+	}
+}
+
+func TestLoad_EnvMachineLearningURL(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("IMMICH_URL", "http://immich:2283")
+	t.Setenv("IMMICH_API_KEY", "env-key")
+	t.Setenv("IMMICH_MACHINE_LEARNING_URL", "http://immich-ml:3003")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Immich.MachineLearningURL != "http://immich-ml:3003" {
+		t.Errorf("unexpected machine learning URL: %s", cfg.Immich.MachineLearningURL)
+	}
+}
+
+func TestLoad_Targets(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, `
+targets:
+  prod:
+    url: http://immich-prod:2283
+    api_key: prod-key
+  staging:
+    url: http://immich-staging:2283
+    api_key: staging-key
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(cfg.Targets) != 2 {
+		t.Fatalf("expected 2 targets, got %d", len(cfg.Targets))
+	}
+	if cfg.Targets["prod"].URL != "http://immich-prod:2283" {
+		t.Errorf("unexpected prod URL: %s", cfg.Targets["prod"].URL)
+	}
+	if names := cfg.TargetNames(); names[0] != "prod" || names[1] != "staging" {
+		t.Errorf("expected sorted target names, got %v", names)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "unknown field",
+			content: "immich:\n  url: http://immich:2283\n  api_key: key\n  apikey: typo\n",
+			wantErr: "line 4: field apikey not found",
+		},
+		{
+			name:    "no instance",
+			content: "web:\n  listen_address: \":8080\"\n",
+			wantErr: "immich.url is required",
+		},
+		{
+			name:    "bad url",
+			content: "immich:\n  url: immich:2283\n  api_key: key\n",
+			wantErr: "immich.url",
+		},
+		{
+			name:    "missing key",
+			content: "immich:\n  url: http://immich:2283\n",
+			wantErr: "immich: one of api_key or api_key_file is required",
+		},
+		{
+			name:    "both keys",
+			content: "immich:\n  url: http://immich:2283\n  api_key: key\n  api_key_file: /key\n",
+			wantErr: "mutually exclusive",
+		},
+		{
+			name:    "bad timeout",
+			content: "immich:\n  url: http://immich:2283\n  api_key: key\n  timeout: -1s\n",
+			wantErr: "immich.timeout",
+		},
+		{
+			name:    "negative polling interval",
+			content: "immich:\n  url: http://immich:2283\n  api_key: key\npolling:\n  interval: -1m\n",
+			wantErr: "polling.interval",
+		},
+		{
+			name:    "unknown collector",
+			content: "immich:\n  url: http://immich:2283\n  api_key: key\ncollectors:\n  albumz: true\n",
+			wantErr: "collectors.albumz: unknown collector",
+		},
+		{
+			name:    "bad label",
+			content: "immich:\n  url: http://immich:2283\n  api_key: key\nlabels:\n  constant:\n    bad-label: x\n",
+			wantErr: "labels.constant.bad-label",
+		},
+		{
+			name:    "bad user label",
+			content: "immich:\n  url: http://immich:2283\n  api_key: key\nlabels:\n  user: email\n",
+			wantErr: "labels.user: unknown user label mode",
+		},
+		{
+			name:    "negative album series",
+			content: "immich:\n  url: http://immich:2283\n  api_key: key\nalbums:\n  max_series: -1\n",
+			wantErr: "albums.max_series",
+		},
+		{
+			name:    "non-positive shared link expiry window",
+			content: "immich:\n  url: http://immich:2283\n  api_key: key\nshared_links:\n  expiry_window: 0s\n",
+			wantErr: "shared_links.expiry_window",
+		},
+		{
+			name:    "invalid machine learning URL",
+			content: "immich:\n  url: http://immich:2283\n  api_key: key\n  machine_learning_url: immich-ml:3003\n",
+			wantErr: "immich.machine_learning_url",
+		},
+		{
+			name:    "non-positive queue stall window",
+			content: "immich:\n  url: http://immich:2283\n  api_key: key\njobs:\n  stall_windows:\n    ocr: 0s\n",
+			wantErr: "jobs.stall_windows.ocr",
+		},
+		{
+			name:    "forecast window shorter than samples",
+			content: "immich:\n  url: http://immich:2283\n  api_key: key\nforecast:\n  window: 1h\n  sample_interval: 1h\n",
+			wantErr: "forecast.window",
+		},
+		{
+			name:    "forecast window with too many samples",
+			content: "immich:\n  url: http://immich:2283\n  api_key: key\nforecast:\n  window: 168h\n  sample_interval: 1m\n",
+			wantErr: "forecast.window",
+		},
+		{
+			name:    "non-positive inventory rescan interval",
+			content: "immich:\n  url: http://immich:2283\n  api_key: key\ninventory:\n  full_rescan_interval: -1h\n",
+			wantErr: "inventory.full_rescan_interval",
+		},
+		{
+			name:    "target without url",
+			content: "targets:\n  prod:\n    api_key: key\n",
+			wantErr: "targets.prod.url",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			_, err := Load(writeConfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
@@ -1,30 +1,63 @@
 package immich
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
 type Client struct {
 	baseURL    string
-	apiKey     string
 	httpClient *http.Client
+
+	mu     sync.RWMutex
+	apiKey string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithTimeout sets the timeout for each request to Immich
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.httpClient.Timeout = timeout
+	}
 }
 
-func NewClient(baseURL, apiKey string) *Client {
+func NewClient(baseURL, apiKey string, opts ...Option) *Client {
 	// Normalize trailing slash
 	baseURL = strings.TrimRight(baseURL, "/")
 
-	return &Client{
+	c := &Client{
 		baseURL: baseURL,
 		apiKey:  apiKey,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// SetAPIKey replaces the API key used for subsequent requests
+func (c *Client) SetAPIKey(apiKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.apiKey = apiKey
+}
+
+func (c *Client) getAPIKey() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.apiKey
 }
 
 // JobsResponse maps queue name to job queue status
@@ -57,6 +90,7 @@ type StatisticsResponse struct {
 }
 
 type UserUsage struct {
+	UserID   string `json:"userId"`
 	UserName string `json:"userName"`
 	Photos   int64  `json:"photos"`
 	Videos   int64  `json:"videos"`
@@ -70,13 +104,182 @@ type StorageResponse struct {
 	DiskUsagePercentage float64 `json:"diskUsagePercentage"`
 }
 
-func (c *Client) doRequest(path string, result interface{}) error {
-	req, err := http.NewRequest("GET", c.baseURL+path, nil)
+// AdminUser is a user as listed by the admin users endpoint
+type AdminUser struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	IsAdmin      bool   `json:"isAdmin"`
+	StorageLabel string `json:"storageLabel"`
+	// QuotaSizeInBytes is nil for users without a quota
+	QuotaSizeInBytes  *int64 `json:"quotaSizeInBytes"`
+	QuotaUsageInBytes int64  `json:"quotaUsageInBytes"`
+	Status            string `json:"status"`
+	DeletedAt         string `json:"deletedAt"`
+}
+
+type Album struct {
+	ID         string `json:"id"`
+	AlbumName  string `json:"albumName"`
+	OwnerID    string `json:"ownerId"`
+	Owner      User   `json:"owner"`
+	AssetCount int64  `json:"assetCount"`
+	Shared     bool   `json:"shared"`
+}
+
+// Library is an external library imported from paths on the server
+type Library struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	OwnerID     string   `json:"ownerId"`
+	ImportPaths []string `json:"importPaths"`
+	AssetCount  int64    `json:"assetCount"`
+	// RefreshedAt is empty for libraries that were never scanned
+	RefreshedAt string `json:"refreshedAt"`
+}
+
+// PeopleResponse is one page of recognized people
+type PeopleResponse struct {
+	People      []Person `json:"people"`
+	Total       int64    `json:"total"`
+	Hidden      int64    `json:"hidden"`
+	HasNextPage bool     `json:"hasNextPage"`
+}
+
+type Person struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	IsHidden bool   `json:"isHidden"`
+}
+
+type User struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// DuplicateGroup is a set of assets Immich detected as duplicates of each other
+type DuplicateGroup struct {
+	DuplicateID string  `json:"duplicateId"`
+	Assets      []Asset `json:"assets"`
+}
+
+// SharedLink is a link giving access to an album or individual assets
+// without logging in
+type SharedLink struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// Password is nil for links anyone with the URL can open
+	Password      *string `json:"password"`
+	AllowDownload bool    `json:"allowDownload"`
+	AllowUpload   bool    `json:"allowUpload"`
+	// ExpiresAt is nil for links that never expire
+	ExpiresAt *string `json:"expiresAt"`
+}
+
+// Session is a login session of a user on one device
+type Session struct {
+	ID         string `json:"id"`
+	CreatedAt  string `json:"createdAt"`
+	UpdatedAt  string `json:"updatedAt"`
+	DeviceType string `json:"deviceType"`
+	DeviceOS   string `json:"deviceOS"`
+	// ExpiresAt is nil for sessions that do not expire
+	ExpiresAt *string `json:"expiresAt"`
+}
+
+type APIKey struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// ServerConfig is the server configuration visible to all users
+type ServerConfig struct {
+	// Days before trashed assets are deleted permanently
+	TrashDays int `json:"trashDays"`
+	// Days before deleted users are removed permanently
+	UserDeleteDelay int `json:"userDeleteDelay"`
+}
+
+// ServerAboutResponse describes the Immich server build and its dependencies
+type ServerAboutResponse struct {
+	Version     string `json:"version"`
+	Build       string `json:"build"`
+	BuildImage  string `json:"buildImage"`
+	SourceRef   string `json:"sourceRef"`
+	Nodejs      string `json:"nodejs"`
+	Ffmpeg      string `json:"ffmpeg"`
+	Imagemagick string `json:"imagemagick"`
+	Libvips     string `json:"libvips"`
+	Exiftool    string `json:"exiftool"`
+	Licensed    bool   `json:"licensed"`
+}
+
+// ServerVersion is an Immich release version
+type ServerVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseServerVersion parses versions like "v1.135.3" as reported by Immich
+func ParseServerVersion(s string) (ServerVersion, error) {
+	var v ServerVersion
+	if _, err := fmt.Sscanf(strings.TrimPrefix(s, "v"), "%d.%d.%d", &v.Major, &v.Minor, &v.Patch); err != nil {
+		return ServerVersion{}, fmt.Errorf("parsing version %q: %w", s, err)
+	}
+	return v, nil
+}
+
+func (v ServerVersion) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Less reports whether v is an older release than other
+func (v ServerVersion) Less(other ServerVersion) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+	return v.Patch < other.Patch
+}
+
+// VersionCheckResponse holds the latest release Immich found on its last
+// version check. ReleaseVersion is empty when version checks are disabled.
+type VersionCheckResponse struct {
+	CheckedAt      string `json:"checkedAt"`
+	ReleaseVersion string `json:"releaseVersion"`
+}
+
+func (c *Client) doRequest(ctx context.Context, path string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	return c.do(req, result)
+}
+
+// doPost sends body as JSON, for endpoints such as search that take their
+// filters in the request body
+func (c *Client) doPost(ctx context.Context, path string, body, result interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encoding request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bytes.NewReader(data))
 	if err != nil {
 		return fmt.Errorf("creating request: %w", err)
 	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, result)
+}
 
-	req.Header.Set("x-api-key", c.apiKey)
+func (c *Client) do(req *http.Request, result interface{}) error {
+	req.Header.Set("x-api-key", c.getAPIKey())
 	req.Header.Set("Accept", "application/json")
 
 	resp, err := c.httpClient.Do(req)
@@ -96,32 +299,168 @@ func (c *Client) doRequest(path string, result interface{}) error {
 	return nil
 }
 
-func (c *Client) GetJobs() (JobsResponse, error) {
+func (c *Client) GetJobs(ctx context.Context) (JobsResponse, error) {
 	var result JobsResponse
-	if err := c.doRequest("/api/jobs", &result); err != nil {
+	if err := c.doRequest(ctx, "/api/jobs", &result); err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
-func (c *Client) GetStatistics() (*StatisticsResponse, error) {
+func (c *Client) GetStatistics(ctx context.Context) (*StatisticsResponse, error) {
 	var result StatisticsResponse
-	if err := c.doRequest("/api/server/statistics", &result); err != nil {
+	if err := c.doRequest(ctx, "/api/server/statistics", &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
-func (c *Client) GetStorage() (*StorageResponse, error) {
+func (c *Client) GetStorage(ctx context.Context) (*StorageResponse, error) {
 	var result StorageResponse
-	if err := c.doRequest("/api/server/storage", &result); err != nil {
+	if err := c.doRequest(ctx, "/api/server/storage", &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetAdminUsers lists all users including soft-deleted ones. It requires an
+// admin API key.
+func (c *Client) GetAdminUsers(ctx context.Context) ([]AdminUser, error) {
+	var result []AdminUser
+	if err := c.doRequest(ctx, "/api/admin/users?withDeleted=true", &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetAlbums lists the albums owned by or shared with the API key's user
+func (c *Client) GetAlbums(ctx context.Context) ([]Album, error) {
+	var result []Album
+	if err := c.doRequest(ctx, "/api/albums", &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetLibraries lists all external libraries. It requires an admin API key.
+func (c *Client) GetLibraries(ctx context.Context) ([]Library, error) {
+	var result []Library
+	if err := c.doRequest(ctx, "/api/libraries", &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetPeople returns one page of people, including hidden ones. Pages start at 1.
+func (c *Client) GetPeople(ctx context.Context, page, size int) (*PeopleResponse, error) {
+	var result PeopleResponse
+	path := fmt.Sprintf("/api/people?withHidden=true&page=%d&size=%d", page, size)
+	if err := c.doRequest(ctx, path, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetDuplicates lists the duplicate groups among the API key's user's assets
+func (c *Client) GetDuplicates(ctx context.Context) ([]DuplicateGroup, error) {
+	var result []DuplicateGroup
+	if err := c.doRequest(ctx, "/api/duplicates", &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetSharedLinks lists the shared links created by the API key's user
+func (c *Client) GetSharedLinks(ctx context.Context) ([]SharedLink, error) {
+	var result []SharedLink
+	if err := c.doRequest(ctx, "/api/shared-links", &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetUserSessions lists the sessions of a user. It requires an admin API key.
+func (c *Client) GetUserSessions(ctx context.Context, userID string) ([]Session, error) {
+	var result []Session
+	if err := c.doRequest(ctx, "/api/admin/users/"+url.PathEscape(userID)+"/sessions", &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetAPIKeys lists the API keys of the API key's user
+func (c *Client) GetAPIKeys(ctx context.Context) ([]APIKey, error) {
+	var result []APIKey
+	if err := c.doRequest(ctx, "/api/api-keys", &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetMyUser returns the user the API key belongs to
+func (c *Client) GetMyUser(ctx context.Context) (*User, error) {
+	var result User
+	if err := c.doRequest(ctx, "/api/users/me", &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetServerConfig returns the server settings that any user can read
+func (c *Client) GetServerConfig(ctx context.Context) (*ServerConfig, error) {
+	var result ServerConfig
+	if err := c.doRequest(ctx, "/api/server/config", &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetServerFeatures returns which optional features are enabled, keyed by
+// feature name
+func (c *Client) GetServerFeatures(ctx context.Context) (map[string]bool, error) {
+	var result map[string]any
+	if err := c.doRequest(ctx, "/api/server/features", &result); err != nil {
+		return nil, err
+	}
+
+	// Skip any non-flag fields newer servers may add
+	features := make(map[string]bool, len(result))
+	for name, value := range result {
+		if enabled, ok := value.(bool); ok {
+			features[name] = enabled
+		}
+	}
+	return features, nil
+}
+
+// GetSystemConfig returns the admin configuration as raw JSON, since only
+// its content as a whole is of interest. It requires an admin API key.
+func (c *Client) GetSystemConfig(ctx context.Context) (json.RawMessage, error) {
+	var result json.RawMessage
+	if err := c.doRequest(ctx, "/api/system-config", &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetServerAbout(ctx context.Context) (*ServerAboutResponse, error) {
+	var result ServerAboutResponse
+	if err := c.doRequest(ctx, "/api/server/about", &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetVersionCheck(ctx context.Context) (*VersionCheckResponse, error) {
+	var result VersionCheckResponse
+	if err := c.doRequest(ctx, "/api/server/version-check", &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
 // Ping checks connectivity to Immich by calling the jobs endpoint
-func (c *Client) Ping() error {
-	_, err := c.GetJobs()
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.GetJobs(ctx)
 	return err
 }
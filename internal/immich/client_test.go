@@ -1,10 +1,12 @@
 package immich
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestNewClient_NormalizesTrailingSlash(t *testing.T) {
@@ -14,6 +16,13 @@ func TestNewClient_NormalizesTrailingSlash(t *testing.T) {
 	}
 }
 
+func TestNewClient_WithTimeout(t *testing.T) {
+	client := NewClient("http://localhost:2283", "test-key", WithTimeout(3*time.Second))
+	if client.httpClient.Timeout != 3*time.Second {
+		t.Errorf("expected 3s timeout, got %s", client.httpClient.Timeout)
+	}
+}
+
 func TestGetJobs(t *testing.T) {
 	expected := JobsResponse{
 		"thumbnailGeneration": {
@@ -38,7 +47,7 @@ func TestGetJobs(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(server.URL, "test-key")
-	result, err := client.GetJobs()
+	result, err := client.GetJobs(context.Background())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -71,7 +80,7 @@ func TestGetStatistics(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(server.URL, "test-key")
-	result, err := client.GetStatistics()
+	result, err := client.GetStatistics(context.Background())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -100,7 +109,7 @@ func TestGetStorage(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(server.URL, "test-key")
-	result, err := client.GetStorage()
+	result, err := client.GetStorage(context.Background())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -117,7 +126,7 @@ func TestGetJobs_Error(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(server.URL, "bad-key")
-	result, err := client.GetJobs()
+	result, err := client.GetJobs(context.Background())
 	if err == nil {
 		t.Error("expected error for 401 response")
 	}
@@ -133,14 +142,298 @@ func TestPing(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(server.URL, "test-key")
-	if err := client.Ping(); err != nil {
+	if err := client.Ping(context.Background()); err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
 
 func TestPing_Error(t *testing.T) {
 	client := NewClient("http://localhost:99999", "test-key")
-	if err := client.Ping(); err == nil {
+	if err := client.Ping(context.Background()); err == nil {
 		t.Error("expected error for unreachable server")
 	}
 }
+
+func TestGetJobs_ContextDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client := NewClient(server.URL, "test-key")
+	start := time.Now()
+	if _, err := client.GetJobs(ctx); err == nil {
+		t.Error("expected error when context deadline is exceeded")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected request to be cancelled by the context, took %s", elapsed)
+	}
+}
+
+func TestGetServerAbout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/server/about" {
+			t.Errorf("expected path /api/server/about, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"version":"v1.135.3","build":"15905678","nodejs":"v22.14.0","ffmpeg":"7.0.2-7","licensed":false}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	result, err := client.GetServerAbout(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Version != "v1.135.3" || result.Nodejs != "v22.14.0" {
+		t.Errorf("unexpected about response: %+v", result)
+	}
+}
+
+func TestParseServerVersion(t *testing.T) {
+	v, err := ParseServerVersion("v1.136.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !(ServerVersion{Major: 1, Minor: 135, Patch: 3}).Less(v) {
+		t.Errorf("expected v1.135.3 to be older than %s", v)
+	}
+	if v.Less(ServerVersion{Major: 1, Minor: 136, Patch: 0}) {
+		t.Errorf("expected %s not to be older than itself", v)
+	}
+
+	if _, err := ParseServerVersion("latest"); err == nil {
+		t.Error("expected error for invalid version")
+	}
+}
+
+func TestGetAdminUsers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/admin/users" {
+			t.Errorf("expected path /api/admin/users, got %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("withDeleted") != "true" {
+			t.Errorf("expected deleted users to be requested, got %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`[
+			{"id":"u1","name":"alice","isAdmin":true,"quotaSizeInBytes":1000,"quotaUsageInBytes":900,"status":"active"},
+			{"id":"u2","name":"bob","quotaSizeInBytes":null,"quotaUsageInBytes":5,"status":"deleted","deletedAt":"2025-01-01T00:00:00.000Z"}
+		]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	result, err := client.GetAdminUsers(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result) != 2 {
+		t.Fatalf("expected 2 users, got %d", len(result))
+	}
+	if result[0].QuotaSizeInBytes == nil || *result[0].QuotaSizeInBytes != 1000 {
+		t.Errorf("expected quota of 1000 bytes for alice, got %v", result[0].QuotaSizeInBytes)
+	}
+	if result[1].QuotaSizeInBytes != nil {
+		t.Errorf("expected no quota for bob, got %d", *result[1].QuotaSizeInBytes)
+	}
+}
+
+func TestGetAlbums(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/albums" {
+			t.Errorf("expected path /api/albums, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`[{"id":"a1","albumName":"Holidays","ownerId":"u1","owner":{"id":"u1","name":"alice"},"assetCount":42,"shared":true}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	result, err := client.GetAlbums(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result) != 1 || result[0].AssetCount != 42 || result[0].Owner.Name != "alice" {
+		t.Errorf("unexpected albums: %+v", result)
+	}
+}
+
+func TestGetLibraries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/libraries" {
+			t.Errorf("expected path /api/libraries, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`[
+			{"id":"l1","name":"NAS","importPaths":["/mnt/photos","/mnt/scans"],"assetCount":1200,"refreshedAt":"2025-07-01T12:00:00.000Z"},
+			{"id":"l2","name":"New","importPaths":[],"assetCount":0,"refreshedAt":null}
+		]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	result, err := client.GetLibraries(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result) != 2 || len(result[0].ImportPaths) != 2 || result[1].RefreshedAt != "" {
+		t.Errorf("unexpected libraries: %+v", result)
+	}
+}
+
+func TestGetPeople(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/people" {
+			t.Errorf("expected path /api/people, got %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("withHidden") != "true" || r.URL.Query().Get("page") != "2" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"people":[{"id":"p1","name":"Alice","isHidden":false}],"total":1001,"hidden":3,"hasNextPage":false}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	result, err := client.GetPeople(context.Background(), 2, 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Total != 1001 || result.Hidden != 3 || len(result.People) != 1 {
+		t.Errorf("unexpected people response: %+v", result)
+	}
+}
+
+func TestGetServerConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/server/config" {
+			t.Errorf("expected path /api/server/config, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"trashDays":30,"userDeleteDelay":7,"loginPageMessage":""}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	result, err := client.GetServerConfig(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.TrashDays != 30 {
+		t.Errorf("expected 30 trash days, got %d", result.TrashDays)
+	}
+}
+
+func TestGetDuplicates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/duplicates" {
+			t.Errorf("expected path /api/duplicates, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`[{"duplicateId":"d1","assets":[
+			{"id":"a1","exifInfo":{"fileSizeInByte":100}},
+			{"id":"a2","exifInfo":{"fileSizeInByte":200}}
+		]}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	result, err := client.GetDuplicates(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result) != 1 || len(result[0].Assets) != 2 {
+		t.Fatalf("unexpected duplicates: %+v", result)
+	}
+	if result[0].Assets[1].ExifInfo.FileSizeInByte != 200 {
+		t.Errorf("expected size 200, got %d", result[0].Assets[1].ExifInfo.FileSizeInByte)
+	}
+}
+
+func TestGetSharedLinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/shared-links" {
+			t.Errorf("expected path /api/shared-links, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`[{"id":"s1","type":"ALBUM","password":null,"allowDownload":true,"expiresAt":"2025-07-03T12:00:00.000Z"}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	result, err := client.GetSharedLinks(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result) != 1 {
+		t.Fatalf("expected 1 shared link, got %d", len(result))
+	}
+	if result[0].Password != nil || result[0].ExpiresAt == nil || !result[0].AllowDownload {
+		t.Errorf("unexpected shared link: %+v", result[0])
+	}
+}
+
+func TestGetUserSessions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/admin/users/u1/sessions" {
+			t.Errorf("expected path /api/admin/users/u1/sessions, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`[{"id":"s1","createdAt":"2025-01-01T00:00:00.000Z","deviceType":"iOS","deviceOS":"iOS 18","expiresAt":null}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	result, err := client.GetUserSessions(context.Background(), "u1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result) != 1 || result[0].DeviceType != "iOS" {
+		t.Errorf("unexpected sessions: %+v", result)
+	}
+}
+
+func TestGetAPIKeys(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/api-keys" {
+			t.Errorf("expected path /api/api-keys, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`[{"id":"k1","name":"exporter","createdAt":"2025-01-01T00:00:00.000Z","permissions":["all"]}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	result, err := client.GetAPIKeys(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result) != 1 || result[0].Name != "exporter" {
+		t.Errorf("unexpected API keys: %+v", result)
+	}
+}
+
+func TestGetServerFeatures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/server/features" {
+			t.Errorf("expected path /api/server/features, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"smartSearch":true,"oauth":false,"future":"value"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	result, err := client.GetServerFeatures(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result) != 2 || !result["smartSearch"] || result["oauth"] {
+		t.Errorf("unexpected features: %v", result)
+	}
+}
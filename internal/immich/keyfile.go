@@ -0,0 +1,83 @@
+package immich
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// KeyFile keeps a Client's API key in sync with a file on disk, such as a
+// Docker or Kubernetes secret mount
+type KeyFile struct {
+	path   string
+	client *Client
+}
+
+func NewKeyFile(path string, client *Client) *KeyFile {
+	return &KeyFile{path: path, client: client}
+}
+
+// ReadAPIKeyFile reads an API key from path, ignoring surrounding whitespace
+func ReadAPIKeyFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading API key file: %w", err)
+	}
+
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("API key file %s is empty", path)
+	}
+	return key, nil
+}
+
+// Reload re-reads the key file and swaps the client's key if it changed.
+// On error the previous key stays in use.
+func (k *KeyFile) Reload() (changed bool, err error) {
+	key, err := ReadAPIKeyFile(k.path)
+	if err != nil {
+		return false, err
+	}
+
+	if key == k.client.getAPIKey() {
+		return false, nil
+	}
+	k.client.SetAPIKey(key)
+	return true, nil
+}
+
+// Watch reloads the key file every interval until ctx is cancelled
+func (k *KeyFile) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			k.reloadAndLog()
+		}
+	}
+}
+
+func (k *KeyFile) reloadAndLog() {
+	changed, err := k.Reload()
+	if err != nil {
+		log.Printf("Error reloading API key from %s: %v", k.path, err)
+		return
+	}
+	if changed {
+		log.Printf("Reloaded API key from %s", k.path)
+	}
+}
+
+// ReloadAll reloads every key file, logging the outcome. It is used on SIGHUP.
+func ReloadAll(keyFiles []*KeyFile) {
+	for _, k := range keyFiles {
+		k.reloadAndLog()
+	}
+}
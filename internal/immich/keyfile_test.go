@@ -0,0 +1,104 @@
+package immich
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeKey(t *testing.T, path, key string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(key), 0o600); err != nil {
+		t.Fatalf("writing key file: %v", err)
+	}
+}
+
+func TestReadAPIKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	writeKey(t, path, "secret-key\n")
+
+	key, err := ReadAPIKeyFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "secret-key" {
+		t.Errorf("expected trimmed key, got %q", key)
+	}
+}
+
+func TestReadAPIKeyFile_Empty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	writeKey(t, path, "  \n")
+
+	if _, err := ReadAPIKeyFile(path); err == nil {
+		t.Error("expected error for empty key file")
+	}
+}
+
+func TestKeyFile_Reload(t *testing.T) {
+	var gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("x-api-key")
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "key")
+	writeKey(t, path, "old-key")
+
+	client := NewClient(server.URL, "")
+	keyFile := NewKeyFile(path, client)
+	if _, err := keyFile.Reload(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client.Ping(context.Background())
+	if gotKey != "old-key" {
+		t.Errorf("expected old-key, got %q", gotKey)
+	}
+
+	writeKey(t, path, "new-key")
+	changed, err := keyFile.Reload()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !changed {
+		t.Error("expected key change to be reported")
+	}
+	client.Ping(context.Background())
+	if gotKey != "new-key" {
+		t.Errorf("expected new-key, got %q", gotKey)
+	}
+
+	// A broken file keeps the previous key
+	writeKey(t, path, "")
+	if _, err := keyFile.Reload(); err == nil {
+		t.Error("expected error for empty key file")
+	}
+	client.Ping(context.Background())
+	if gotKey != "new-key" {
+		t.Errorf("expected new-key to stay in use, got %q", gotKey)
+	}
+}
+
+func TestKeyFile_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	writeKey(t, path, "old-key")
+
+	client := NewClient("http://localhost", "old-key")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go NewKeyFile(path, client).Watch(ctx, 10*time.Millisecond)
+
+	writeKey(t, path, "new-key")
+	deadline := time.Now().Add(time.Second)
+	for client.getAPIKey() != "new-key" {
+		if time.Now().After(deadline) {
+			t.Fatal("key was not reloaded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,42 @@
+package immich
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MachineLearningClient checks the Immich machine learning service, which
+// smart search and face detection depend on
+type MachineLearningClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewMachineLearningClient(baseURL string, timeout time.Duration) *MachineLearningClient {
+	return &MachineLearningClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Ping calls the service's ping endpoint, failing unless it answers 200 OK
+func (c *MachineLearningClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/ping", nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
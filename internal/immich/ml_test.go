@@ -0,0 +1,36 @@
+package immich
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMachineLearningPing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ping" {
+			t.Errorf("expected path /ping, got %s", r.URL.Path)
+		}
+		w.Write([]byte("pong"))
+	}))
+	defer server.Close()
+
+	client := NewMachineLearningClient(server.URL+"/", time.Second)
+	if err := client.Ping(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMachineLearningPing_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewMachineLearningClient(server.URL, time.Second)
+	if err := client.Ping(context.Background()); err == nil {
+		t.Error("expected error for unavailable service")
+	}
+}
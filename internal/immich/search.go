@@ -0,0 +1,116 @@
+package immich
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+)
+
+// Largest page size the search endpoints accept
+const maxSearchPageSize = 1000
+
+// MetadataSearchRequest filters assets for SearchAssets. Page and Size are
+// set by ForEachAsset when paging.
+type MetadataSearchRequest struct {
+	Page        int  `json:"page,omitempty"`
+	Size        int  `json:"size,omitempty"`
+	WithPeople  bool `json:"withPeople,omitempty"`
+	WithExif    bool `json:"withExif,omitempty"`
+	WithDeleted bool `json:"withDeleted,omitempty"`
+	// TrashedAfter selects trashed assets only (requires WithDeleted)
+	TrashedAfter string `json:"trashedAfter,omitempty"`
+	// UpdatedAfter selects assets changed since a previous search
+	UpdatedAfter string `json:"updatedAfter,omitempty"`
+}
+
+// StatisticsSearchRequest filters assets for CountAssets
+type StatisticsSearchRequest struct {
+	Type string `json:"type,omitempty"`
+	// IsEncoded selects videos with (true) or without (false) a transcoded
+	// version
+	IsEncoded *bool `json:"isEncoded,omitempty"`
+}
+
+type SearchResponse struct {
+	Assets SearchAssetsPage `json:"assets"`
+}
+
+type SearchAssetsPage struct {
+	Total int     `json:"total"`
+	Count int     `json:"count"`
+	Items []Asset `json:"items"`
+	// NextPage is empty on the last page
+	NextPage string `json:"nextPage"`
+}
+
+type Asset struct {
+	ID        string `json:"id"`
+	OwnerID   string `json:"ownerId"`
+	Type      string `json:"type"`
+	IsTrashed bool   `json:"isTrashed"`
+	// CreatedAt is when the asset was uploaded, not when it was taken
+	CreatedAt        string `json:"createdAt"`
+	UpdatedAt        string `json:"updatedAt"`
+	OriginalFileName string `json:"originalFileName"`
+	OriginalMimeType string `json:"originalMimeType"`
+	// LivePhotoVideoID is set on the still image of a live photo
+	LivePhotoVideoID *string `json:"livePhotoVideoId"`
+	// Thumbhash is nil until a thumbnail has been generated
+	Thumbhash *string `json:"thumbhash"`
+	// ExifInfo is only set with WithExif, and nil until metadata extraction ran
+	ExifInfo *ExifInfo `json:"exifInfo"`
+	// People and UnassignedFaces are only set with WithPeople
+	People          []Person          `json:"people"`
+	UnassignedFaces []json.RawMessage `json:"unassignedFaces"`
+}
+
+type ExifInfo struct {
+	Make           string `json:"make"`
+	Model          string `json:"model"`
+	FileSizeInByte int64  `json:"fileSizeInByte"`
+}
+
+// SearchAssets returns one page of assets owned by the API key's user
+func (c *Client) SearchAssets(ctx context.Context, req MetadataSearchRequest) (*SearchAssetsPage, error) {
+	var result SearchResponse
+	if err := c.doPost(ctx, "/api/search/metadata", req, &result); err != nil {
+		return nil, err
+	}
+	return &result.Assets, nil
+}
+
+// ForEachAsset pages through all assets matching req, calling fn for each
+func (c *Client) ForEachAsset(ctx context.Context, req MetadataSearchRequest, fn func(Asset)) error {
+	req.Page = 1
+	req.Size = maxSearchPageSize
+
+	for {
+		page, err := c.SearchAssets(ctx, req)
+		if err != nil {
+			return err
+		}
+		for _, asset := range page.Items {
+			fn(asset)
+		}
+
+		if page.NextPage == "" {
+			return nil
+		}
+		next, err := strconv.Atoi(page.NextPage)
+		if err != nil || next <= req.Page {
+			return nil
+		}
+		req.Page = next
+	}
+}
+
+// CountAssets returns the number of assets of the API key's user matching req
+func (c *Client) CountAssets(ctx context.Context, req StatisticsSearchRequest) (int64, error) {
+	var result struct {
+		Total int64 `json:"total"`
+	}
+	if err := c.doPost(ctx, "/api/search/statistics", req, &result); err != nil {
+		return 0, err
+	}
+	return result.Total, nil
+}
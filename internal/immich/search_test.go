@@ -0,0 +1,91 @@
+package immich
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestForEachAsset(t *testing.T) {
+	var pages []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/search/metadata" {
+			t.Errorf("expected POST /api/search/metadata, got %s %s", r.Method, r.URL.Path)
+		}
+
+		var req MetadataSearchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		if !req.WithPeople {
+			t.Error("expected withPeople filter to be sent")
+		}
+		pages = append(pages, req.Page)
+
+		switch req.Page {
+		case 1:
+			w.Write([]byte(`{"assets":{"items":[{"id":"a1"},{"id":"a2"}],"nextPage":"2"}}`))
+		case 2:
+			w.Write([]byte(`{"assets":{"items":[{"id":"a3"}],"nextPage":null}}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	var ids []string
+	err := client.ForEachAsset(context.Background(), MetadataSearchRequest{WithPeople: true}, func(asset Asset) {
+		ids = append(ids, asset.ID)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(ids) != 3 {
+		t.Errorf("expected 3 assets, got %v", ids)
+	}
+	if len(pages) != 2 || pages[0] != 1 || pages[1] != 2 {
+		t.Errorf("expected pages 1 and 2, got %v", pages)
+	}
+}
+
+func TestForEachAsset_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	if err := client.ForEachAsset(context.Background(), MetadataSearchRequest{}, func(Asset) {}); err == nil {
+		t.Error("expected error for 403 response")
+	}
+}
+
+func TestCountAssets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/search/statistics" {
+			t.Errorf("expected POST /api/search/statistics, got %s %s", r.Method, r.URL.Path)
+		}
+
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		if body["type"] != "VIDEO" || body["isEncoded"] != false {
+			t.Errorf("unexpected filters: %v", body)
+		}
+		w.Write([]byte(`{"total":17}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	encoded := false
+	total, err := client.CountAssets(context.Background(), StatisticsSearchRequest{Type: "VIDEO", IsEncoded: &encoded})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 17 {
+		t.Errorf("expected 17 assets, got %d", total)
+	}
+}
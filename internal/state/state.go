@@ -0,0 +1,111 @@
+// Package state persists what collectors derive across scrapes, such as
+// counters and sample windows, so it survives exporter restarts
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store holds JSON values by key, written to a file on every change.
+// Stores returned by Scope share the file of their parent.
+type Store struct {
+	file   *file
+	prefix string
+}
+
+type file struct {
+	mu   sync.Mutex
+	path string
+	data map[string]json.RawMessage
+}
+
+// Open loads the store at path, starting empty if the file does not exist
+func Open(path string) (*Store, error) {
+	data := make(map[string]json.RawMessage)
+	content, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("reading state file: %w", err)
+	default:
+		if err := json.Unmarshal(content, &data); err != nil {
+			return nil, fmt.Errorf("parsing state file %s: %w", path, err)
+		}
+	}
+	return &Store{file: &file{path: path, data: data}}, nil
+}
+
+// Memory returns a store that is never written to disk
+func Memory() *Store {
+	return &Store{file: &file{data: make(map[string]json.RawMessage)}}
+}
+
+// Scope returns a view of the store whose keys are prefixed with name, so
+// several instances can keep their state in one file
+func (s *Store) Scope(name string) *Store {
+	return &Store{file: s.file, prefix: s.prefix + name + "/"}
+}
+
+// Get decodes the value stored at key into v and reports whether it existed
+func (s *Store) Get(key string, v any) (bool, error) {
+	s.file.mu.Lock()
+	raw, ok := s.file.data[s.prefix+key]
+	s.file.mu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, fmt.Errorf("decoding state %s: %w", s.prefix+key, err)
+	}
+	return true, nil
+}
+
+// Set stores v at key and writes the store to disk
+func (s *Store) Set(key string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding state %s: %w", s.prefix+key, err)
+	}
+
+	s.file.mu.Lock()
+	defer s.file.mu.Unlock()
+	s.file.data[s.prefix+key] = raw
+	return s.file.write()
+}
+
+// write replaces the file through a rename, so a crash never leaves it
+// half written
+func (f *file) write() error {
+	if f.path == "" {
+		return nil
+	}
+
+	content, err := json.Marshal(f.data)
+	if err != nil {
+		return fmt.Errorf("encoding state file: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	return nil
+}
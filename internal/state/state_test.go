@@ -0,0 +1,70 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type counters struct {
+	Uploads int `json:"uploads"`
+}
+
+func TestStore_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Scope("prod").Set("uploads", counters{Uploads: 3}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got counters
+	ok, err := reopened.Scope("prod").Get("uploads", &got)
+	if err != nil || !ok {
+		t.Fatalf("expected stored value, got ok=%v err=%v", ok, err)
+	}
+	if got.Uploads != 3 {
+		t.Errorf("expected 3 uploads, got %d", got.Uploads)
+	}
+}
+
+func TestStore_Scopes(t *testing.T) {
+	store := Memory()
+	store.Scope("prod").Set("uploads", counters{Uploads: 1})
+
+	var got counters
+	if ok, _ := store.Scope("staging").Get("uploads", &got); ok {
+		t.Error("expected scopes to be independent")
+	}
+	if ok, _ := store.Get("uploads", &got); ok {
+		t.Error("expected scoped keys to be hidden from the parent")
+	}
+}
+
+func TestOpen_Missing(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got counters
+	if ok, err := store.Get("uploads", &got); ok || err != nil {
+		t.Errorf("expected empty store, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestOpen_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatalf("writing state: %v", err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("expected error for corrupt state file")
+	}
+}